@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/config"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/features/wallets"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const (
+	exitOk      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// ErrUsage is returned by commands that were called with invalid arguments.
+// The usage of the command is printed.
+var ErrUsage = errors.New("invalid usage")
+
+// Command is a subcommand of the sparovec executable.
+type Command struct {
+	Name string
+	// Positional arguments, shown in the usage.
+	Args    string
+	Summary string
+	// Longer description, shown in the help of the command.
+	Help string
+
+	// Run defines the flags of the command and parses the arguments with Parse.
+	Run func(ctx context.Context, flags *flag.FlagSet, args []string) error
+}
+
+type App struct {
+	commands []Command
+
+	conf             *config.Config
+	auth             *auth.Auth
+	walletRepository *wallets.Repository
+
+	stdin  *os.File
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func New(conf *config.Config, db *sqlx.DB, log *slog.Logger) *App {
+	app := &App{
+		conf:             conf,
+		auth:             auth.New(auth.NewRepository(db), nil, conf, log),
+		walletRepository: wallets.NewRepository(db),
+
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+
+	app.commands = append(app.commands, app.userCommands()...)
+	app.commands = append(app.commands, app.walletCommands()...)
+	app.commands = append(app.commands, app.generateSigningKeyCommand())
+
+	return app
+}
+
+// Add adds a command that is defined outside of this package.
+// Such commands are listed first.
+func (a *App) Add(command Command) {
+	a.commands = append([]Command{command}, a.commands...)
+}
+
+// Run runs the command given by the arguments and returns the exit code.
+func (a *App) Run(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		a.printUsage(a.stderr)
+		return exitUsage
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		if len(args) > 1 {
+			if command := a.command(args[1]); command != nil {
+				// Flags are defined when the command runs, so let it print its own help.
+				_ = command.Run(ctx, a.flagSet(command, a.stdout), []string{"-h"})
+				return exitOk
+			}
+		}
+
+		a.printUsage(a.stdout)
+		return exitOk
+	}
+
+	command := a.command(name)
+	if command == nil {
+		fmt.Fprintf(a.stderr, "Unknown command %q\n\n", name)
+		a.printUsage(a.stderr)
+		return exitUsage
+	}
+
+	flags := a.flagSet(command, a.stderr)
+	err := command.Run(ctx, flags, args[1:])
+	switch {
+	case err == nil:
+		return exitOk
+	case errors.Is(err, flag.ErrHelp):
+		return exitOk
+	case errors.Is(err, ErrUsage):
+		flags.Usage()
+		return exitUsage
+	case isFlagError(err):
+		// The flag package already printed the error and the usage.
+		return exitUsage
+	default:
+		fmt.Fprintf(a.stderr, "Error: %s\n", errorMessage(err))
+		return exitFailure
+	}
+}
+
+func (a *App) command(name string) *Command {
+	for i := range a.commands {
+		if a.commands[i].Name == name {
+			return &a.commands[i]
+		}
+	}
+
+	return nil
+}
+
+func (a *App) flagSet(command *Command, output io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintf(output, "Usage: sparovec %s", command.Name)
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprint(output, " [flags]")
+		}
+		if command.Args != "" {
+			fmt.Fprintf(output, " %s", command.Args)
+		}
+		fmt.Fprintf(output, "\n\n%s\n", command.Summary)
+
+		if command.Help != "" {
+			fmt.Fprintf(output, "\n%s\n", command.Help)
+		}
+
+		if hasFlags {
+			fmt.Fprint(output, "\nFlags:\n")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags
+}
+
+func (a *App) printUsage(output io.Writer) {
+	fmt.Fprint(output, "Usage: sparovec <command> [flags] [arguments]\n\nCommands:\n")
+
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	for _, command := range a.commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", command.Name, command.Args, command.Summary)
+	}
+	_ = w.Flush()
+
+	fmt.Fprint(output, "\nRun 'sparovec help <command>' for more information about a command.\n")
+}
+
+// Parse parses the flags and checks the number of positional arguments.
+func Parse(flags *flag.FlagSet, args []string, nrArgs int) error {
+	err := flags.Parse(args)
+	if err != nil {
+		return flagError{err}
+	}
+
+	if flags.NArg() != nrArgs {
+		return ErrUsage
+	}
+
+	return nil
+}
+
+type flagError struct {
+	err error
+}
+
+func (e flagError) Error() string {
+	return e.err.Error()
+}
+
+func (e flagError) Unwrap() error {
+	return e.err
+}
+
+func isFlagError(err error) bool {
+	var flagErr flagError
+	return errors.As(err, &flagErr)
+}
+
+// errorMessage returns a message for the errors returned by the services.
+func errorMessage(err error) string {
+	var invalidForm *models.ErrInvalidForm
+	switch {
+	case errors.As(err, &invalidForm):
+		return invalidForm.Message
+	case errors.Is(err, models.ErrInternalServer):
+		return "internal error, see the logs for details"
+	default:
+		return err.Error()
+	}
+}
+
+// confirm asks the user to confirm the action. Anything but yes is a no.
+func (a *App) confirm(question string) bool {
+	fmt.Fprintf(a.stdout, "%s [y/N] ", question)
+
+	var answer string
+	_, _ = fmt.Fscanln(a.stdin, &answer)
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func (a *App) table() *tabwriter.Writer {
+	return tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// readPassword reads the password from the first line of stdin if fromStdin is set.
+// Otherwise it prompts for the password twice, without echoing it.
+// Passwords are never taken as arguments, since those end up in the shell history.
+func (a *App) readPassword(fromStdin bool) (string, error) {
+	if fromStdin {
+		line, err := bufio.NewReader(a.stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fd := int(a.stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("stdin is not a terminal, use --stdin to read the password from it")
+	}
+
+	fmt.Fprint(a.stdout, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(a.stdout)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	fmt.Fprint(a.stdout, "Repeat password: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(a.stdout)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	if string(password) != string(repeated) {
+		return "", errors.New("passwords don't match")
+	}
+
+	return string(password), nil
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/viddrobnic/sparovec/config"
+)
+
+func (a *App) generateSigningKeyCommand() Command {
+	return Command{
+		Name:    "generate-signing-key",
+		Summary: "Generates a new key for signing sessions",
+		Help:    "Prints the config changes for rotating the key without signing everyone out.",
+		Run:     a.generateSigningKey,
+	}
+}
+
+func (a *App) generateSigningKey(_ context.Context, flags *flag.FlagSet, args []string) error {
+	err := Parse(flags, args, 0)
+	if err != nil {
+		return err
+	}
+
+	key, err := config.GenerateSigningKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	// Sessions signed with the current key are valid for at most session_ttl,
+	// so the key can be retired after that.
+	acceptUntil := time.Now().UTC().Add(time.Duration(a.conf.Auth.SessionTtl) * time.Second)
+
+	fmt.Fprintln(a.stdout, "Replace the signing key in the [auth] section of the config with:")
+	fmt.Fprintln(a.stdout)
+	fmt.Fprintf(a.stdout, "signing_key_id = %q\n", config.GenerateSigningKeyId())
+	fmt.Fprintf(a.stdout, "signing_key = %q\n", key)
+	fmt.Fprintln(a.stdout)
+
+	// Anyone could have signed sessions with a weak key, so it must not be accepted anymore.
+	if !a.conf.Auth.IsSigningKeySecure() {
+		fmt.Fprintln(a.stdout, "The current key is not a random secret, so it can't be retired.")
+		fmt.Fprintln(a.stdout, "Everyone has to sign in again.")
+		return nil
+	}
+
+	fmt.Fprintln(a.stdout, "and retire the current key, so that existing sessions stay valid:")
+	fmt.Fprintln(a.stdout)
+	fmt.Fprintln(a.stdout, "[[auth.retired_signing_keys]]")
+	fmt.Fprintf(a.stdout, "id = %q\n", a.conf.Auth.SigningKeyId)
+	fmt.Fprintf(a.stdout, "key = %q\n", a.conf.Auth.SigningKey)
+	fmt.Fprintf(a.stdout, "accept_until = %q\n", acceptUntil.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,318 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+func (a *App) userCommands() []Command {
+	return []Command{
+		{
+			Name:    "create-user",
+			Args:    "<username>",
+			Summary: "Creates a new user",
+			Help:    "The password is prompted for, or read from stdin with --stdin.",
+			Run:     a.createUser,
+		},
+		{
+			Name:    "grant-admin",
+			Args:    "<username>",
+			Summary: "Makes a user an admin",
+			Help:    "Admins can manage users and see instance stats and logs in the admin panel.",
+			Run:     a.grantAdmin,
+		},
+		{
+			Name:    "revoke-admin",
+			Args:    "<username>",
+			Summary: "Makes an admin a regular user",
+			Run:     a.revokeAdmin,
+		},
+		{
+			Name:    "list-users",
+			Summary: "Lists all users",
+			Run:     a.listUsers,
+		},
+		{
+			Name:    "rename-user",
+			Args:    "<username> <new-username>",
+			Summary: "Changes the username of a user",
+			Help:    "The user is signed out everywhere.",
+			Run:     a.renameUser,
+		},
+		{
+			Name:    "delete-user",
+			Args:    "<username>",
+			Summary: "Deletes a user",
+			Help: "Wallets that no one else is a member of are deleted together with the user.\n" +
+				"Wallets where the user is the only owner get the remaining editors as owners,\n" +
+				"or the viewers if there are no editors.",
+			Run: a.deleteUser,
+		},
+		{
+			Name:    "set-password",
+			Args:    "<username>",
+			Summary: "Sets the password of a user",
+			Help: "The password is prompted for, or read from stdin with --stdin.\n" +
+				"The user is signed out everywhere.",
+			Run: a.setPassword,
+		},
+		{
+			Name:    "reset-password",
+			Args:    "<username>",
+			Summary: "Prints a one-time link for choosing a new password",
+			Help: fmt.Sprintf(
+				"The link is valid for %d hours and uses public_url from the [api] section of the config.\n"+
+					"Use set-password to set the password directly.",
+				int(auth.PasswordResetTtl.Hours()),
+			),
+			Run: a.resetPassword,
+		},
+		{
+			Name:    "create-invite",
+			Summary: "Prints a single-use link with which someone can sign up",
+			Help: fmt.Sprintf(
+				"The link is valid for %d days and uses public_url from the [api] section of the config.",
+				int(auth.InviteTtl.Hours()/24),
+			),
+			Run: a.createInvite,
+		},
+		{
+			Name:    "reset-2fa",
+			Args:    "<username>",
+			Summary: "Disables two-factor authentication for a user",
+			Help:    "Use it when the user lost both the authenticator and the recovery codes.",
+			Run:     a.resetTwoFactor,
+		},
+	}
+}
+
+func (a *App) createUser(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	fromStdin := flags.Bool("stdin", false, "read the password from stdin")
+	isAdmin := flags.Bool("admin", false, "make the user an admin")
+	err := Parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	password, err := a.readPassword(*fromStdin)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.auth.CreateUser(ctx, flags.Arg(0), password, *isAdmin)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "Created user %s with id %d\n", user.Username, user.Id)
+	return nil
+}
+
+func (a *App) listUsers(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	err := Parse(flags, args, 0)
+	if err != nil {
+		return err
+	}
+
+	users, err := a.auth.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := a.table()
+	fmt.Fprintln(w, "ID\tUSERNAME\tADMIN\t2FA\tDISABLED\tCREATED")
+	for _, user := range users {
+		fmt.Fprintf(
+			w,
+			"%d\t%s\t%s\t%s\t%s\t%s\n",
+			user.Id,
+			user.Username,
+			yesNo(user.IsAdmin),
+			yesNo(user.HasTwoFactor()),
+			yesNo(user.IsDisabled()),
+			user.CreatedAt.Format(time.DateOnly),
+		)
+	}
+
+	return w.Flush()
+}
+
+func (a *App) grantAdmin(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	return a.setAdmin(ctx, flags, args, true)
+}
+
+func (a *App) revokeAdmin(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	return a.setAdmin(ctx, flags, args, false)
+}
+
+func (a *App) setAdmin(ctx context.Context, flags *flag.FlagSet, args []string, isAdmin bool) error {
+	err := Parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	err = a.auth.SetUserAdmin(ctx, flags.Arg(0), isAdmin)
+	if err != nil {
+		return userNotFound(err, flags.Arg(0))
+	}
+
+	if isAdmin {
+		fmt.Fprintf(a.stdout, "%s is now an admin\n", flags.Arg(0))
+	} else {
+		fmt.Fprintf(a.stdout, "%s is no longer an admin\n", flags.Arg(0))
+	}
+	return nil
+}
+
+func (a *App) renameUser(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	err := Parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	err = a.auth.RenameUser(ctx, flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		return userNotFound(err, flags.Arg(0))
+	}
+
+	fmt.Fprintf(a.stdout, "Renamed user %s to %s\n", flags.Arg(0), flags.Arg(1))
+	return nil
+}
+
+func (a *App) deleteUser(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	yes := flags.Bool("yes", false, "don't ask for confirmation")
+	err := Parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	username := flags.Arg(0)
+	_, err = a.auth.GetUser(ctx, username)
+	if err != nil {
+		return userNotFound(err, username)
+	}
+
+	question := fmt.Sprintf("Delete user %s and wallets no one else is a member of?", username)
+	if !*yes && !a.confirm(question) {
+		fmt.Fprintln(a.stdout, "Aborted")
+		return nil
+	}
+
+	deletedWallets, err := a.auth.DeleteUser(ctx, username)
+	if err != nil {
+		return userNotFound(err, username)
+	}
+
+	fmt.Fprintf(a.stdout, "Deleted user %s and %d wallets\n", username, deletedWallets)
+	return nil
+}
+
+func (a *App) setPassword(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	fromStdin := flags.Bool("stdin", false, "read the password from stdin")
+	err := Parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	// Fail before prompting for the password.
+	_, err = a.auth.GetUser(ctx, flags.Arg(0))
+	if err != nil {
+		return userNotFound(err, flags.Arg(0))
+	}
+
+	password, err := a.readPassword(*fromStdin)
+	if err != nil {
+		return err
+	}
+
+	err = a.auth.SetPassword(ctx, flags.Arg(0), password)
+	if err != nil {
+		return userNotFound(err, flags.Arg(0))
+	}
+
+	fmt.Fprintln(a.stdout, "Password set, existing sessions were signed out")
+	return nil
+}
+
+func (a *App) resetPassword(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	err := Parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	token, err := a.auth.IssuePasswordReset(ctx, flags.Arg(0))
+	if err != nil {
+		return userNotFound(err, flags.Arg(0))
+	}
+
+	fmt.Fprintf(a.stdout, "%s/auth/reset-password?token=%s\n", a.conf.API.BaseUrl(), token)
+	return nil
+}
+
+func (a *App) createInvite(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	walletId := flags.Int("wallet", 0, "id of the wallet the new user becomes an editor of")
+	err := Parse(flags, args, 0)
+	if err != nil {
+		return err
+	}
+
+	var inviteWalletId *int
+	if *walletId != 0 {
+		wallet, err := a.walletRepository.ForId(ctx, *walletId)
+		if err != nil {
+			return err
+		}
+
+		if wallet == nil {
+			return fmt.Errorf("wallet %d not found", *walletId)
+		}
+
+		inviteWalletId = walletId
+	}
+
+	token, err := a.auth.CreateInvite(ctx, nil, inviteWalletId)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "%s/auth/sign-up?token=%s\n", a.conf.API.BaseUrl(), token)
+	return nil
+}
+
+func (a *App) resetTwoFactor(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	err := Parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	err = a.auth.ResetTwoFactor(ctx, flags.Arg(0))
+	if err != nil {
+		return userNotFound(err, flags.Arg(0))
+	}
+
+	fmt.Fprintf(a.stdout, "Two-factor authentication disabled for %s\n", flags.Arg(0))
+	return nil
+}
+
+// userNotFound replaces models.ErrNotFound with an error that names the user.
+func userNotFound(err error, username string) error {
+	if errors.Is(err, models.ErrNotFound) {
+		return fmt.Errorf("user %s not found", username)
+	}
+
+	return err
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+func (a *App) walletCommands() []Command {
+	return []Command{
+		{
+			Name:    "list-wallets",
+			Summary: "Lists wallets and their members",
+			Run:     a.listWallets,
+		},
+		{
+			Name:    "grant-wallet",
+			Args:    "<username> <wallet-id>",
+			Summary: "Makes a user a member of a wallet",
+			Help:    "If the user is already a member, their role is changed.",
+			Run:     a.grantWallet,
+		},
+		{
+			Name:    "revoke-wallet",
+			Args:    "<username> <wallet-id>",
+			Summary: "Removes a user from a wallet",
+			Help:    "The last owner of a wallet can't be removed.",
+			Run:     a.revokeWallet,
+		},
+	}
+}
+
+func (a *App) listWallets(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	username := flags.String("user", "", "only list wallets of the user")
+	err := Parse(flags, args, 0)
+	if err != nil {
+		return err
+	}
+
+	var wallets []*models.Wallet
+	if *username != "" {
+		user, err := a.auth.GetUser(ctx, *username)
+		if err != nil {
+			return userNotFound(err, *username)
+		}
+
+		wallets, err = a.walletRepository.ForUser(ctx, user.Id)
+		if err != nil {
+			return err
+		}
+	} else {
+		wallets, err = a.walletRepository.List(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	w := a.table()
+	fmt.Fprintln(w, "ID\tNAME\tMEMBERS")
+	for _, wallet := range wallets {
+		members, err := a.walletRepository.Members(ctx, wallet.Id)
+		if err != nil {
+			return err
+		}
+
+		usernames := make([]string, len(members))
+		for i, member := range members {
+			usernames[i] = fmt.Sprintf("%s (%s)", member.Username, member.Role)
+		}
+
+		name := wallet.Name
+		if wallet.DeletedAt.Valid {
+			name += " (in trash)"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", wallet.Id, name, strings.Join(usernames, ", "))
+	}
+
+	return w.Flush()
+}
+
+func (a *App) grantWallet(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	roleFlag := flags.String("role", string(models.RoleEditor), "role of the user: owner, editor or viewer")
+	user, wallet, err := a.parseMembership(ctx, flags, args)
+	if err != nil {
+		return err
+	}
+
+	role := models.Role(*roleFlag)
+	if !role.IsValid() {
+		return fmt.Errorf("invalid role %q", *roleFlag)
+	}
+
+	members, err := a.walletRepository.Members(ctx, wallet.Id)
+	if err != nil {
+		return err
+	}
+
+	member := findMember(members, user.Id)
+	if member == nil {
+		err = a.walletRepository.AddMember(ctx, wallet.Id, user.Id, role)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(a.stdout, "Added %s to wallet %s as %s\n", user.Username, wallet.Name, role)
+		return nil
+	}
+
+	if member.Role == role {
+		return fmt.Errorf("%s is already a member of wallet %s as %s", user.Username, wallet.Name, role)
+	}
+
+	if role != models.RoleOwner && models.IsLastOwner(members, user.Id) {
+		return fmt.Errorf("%s is the last owner of wallet %s", user.Username, wallet.Name)
+	}
+
+	err = a.walletRepository.SetRole(ctx, wallet.Id, user.Id, role)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "Changed role of %s in wallet %s to %s\n", user.Username, wallet.Name, role)
+	return nil
+}
+
+func (a *App) revokeWallet(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	user, wallet, err := a.parseMembership(ctx, flags, args)
+	if err != nil {
+		return err
+	}
+
+	members, err := a.walletRepository.Members(ctx, wallet.Id)
+	if err != nil {
+		return err
+	}
+
+	member := findMember(members, user.Id)
+	if member == nil {
+		return fmt.Errorf("%s is not a member of wallet %s", user.Username, wallet.Name)
+	}
+
+	if member.Role == models.RoleOwner && models.IsLastOwner(members, user.Id) {
+		return fmt.Errorf("%s is the last owner of wallet %s", user.Username, wallet.Name)
+	}
+
+	err = a.walletRepository.RemoveMember(ctx, wallet.Id, user.Id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "Removed %s from wallet %s\n", user.Username, wallet.Name)
+	return nil
+}
+
+// parseMembership parses the <username> <wallet-id> arguments.
+func (a *App) parseMembership(ctx context.Context, flags *flag.FlagSet, args []string) (*models.User, *models.Wallet, error) {
+	err := Parse(flags, args, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	walletId, err := strconv.Atoi(flags.Arg(1))
+	if err != nil {
+		return nil, nil, ErrUsage
+	}
+
+	user, err := a.auth.GetUser(ctx, flags.Arg(0))
+	if err != nil {
+		return nil, nil, userNotFound(err, flags.Arg(0))
+	}
+
+	wallet, err := a.walletRepository.ForId(ctx, walletId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if wallet == nil {
+		return nil, nil, fmt.Errorf("wallet %d not found", walletId)
+	}
+
+	return &user.User, wallet, nil
+}
+
+func findMember(members []*models.Member, userId int) *models.Member {
+	for _, member := range members {
+		if member.Id == userId {
+			return member
+		}
+	}
+
+	return nil
+}
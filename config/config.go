@@ -1,9 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/mitchellh/mapstructure"
@@ -16,17 +20,148 @@ type API struct {
 	ListenAddress      string   `mapstructure:"listen_address"`
 	Port               int      `mapstructure:"port"`
 	CorsAllowedOrigins []string `mapstructure:"cors_allowed_origins"`
+
+	// Url under which users reach the server, used for links generated outside of requests.
+	PublicUrl string `mapstructure:"public_url"`
+}
+
+// BaseUrl returns the public url without the trailing slash.
+// If the public url is not set, the local address is used.
+func (a API) BaseUrl() string {
+	if a.PublicUrl != "" {
+		return strings.TrimSuffix(a.PublicUrl, "/")
+	}
+
+	return fmt.Sprintf("http://localhost:%d", a.Port)
+}
+
+// SigningKey is a key that is no longer used for signing new sessions,
+// but sessions signed with it are still accepted until AcceptUntil.
+type SigningKey struct {
+	Id          string    `mapstructure:"id"`
+	Key         string    `mapstructure:"key"`
+	AcceptUntil time.Time `mapstructure:"accept_until"`
+}
+
+// RateLimit limits failed sign in attempts. Durations are in seconds.
+type RateLimit struct {
+	UserFreeAttempts int    `mapstructure:"user_free_attempts"`
+	IpFreeAttempts   int    `mapstructure:"ip_free_attempts"`
+	BaseLockout      int    `mapstructure:"base_lockout"`
+	MaxLockout       int    `mapstructure:"max_lockout"`
+	ResetAfter       int    `mapstructure:"reset_after"`
+	PersistPath      string `mapstructure:"persist_path"`
+}
+
+// Oidc configures sign in with an OpenID Connect provider, like Authelia or Keycloak.
+type Oidc struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Shown on the sign in button.
+	Name string `mapstructure:"name"`
+
+	// Url of the provider, used for discovery.
+	Issuer       string `mapstructure:"issuer"`
+	ClientId     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// Defaults to <public url>/auth/oidc/callback.
+	RedirectUrl string   `mapstructure:"redirect_url"`
+	Scopes      []string `mapstructure:"scopes"`
+
+	// Claim used as the username of new users.
+	UsernameClaim string `mapstructure:"username_claim"`
+	// Create users on their first sign in.
+	AutoProvision bool `mapstructure:"auto_provision"`
+	// Link existing users with the same username on their first sign in.
+	LinkByUsername bool `mapstructure:"link_by_username"`
+
+	// If set, only members of these groups can sign in.
+	GroupsClaim   string   `mapstructure:"groups_claim"`
+	AllowedGroups []string `mapstructure:"allowed_groups"`
+}
+
+// Proxy trusts the username set by an authenticating reverse proxy.
+type Proxy struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Header with the username, like Remote-User.
+	Header string `mapstructure:"header"`
+	// The header is only trusted from these CIDRs. Client ips from forwarded headers are
+	// only used to limit sign in attempts on requests from them, even if proxy auth is disabled.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// Create users the first time they are seen.
+	AutoCreate bool `mapstructure:"auto_create"`
+}
+
+// TrustedNetworks parses the trusted proxies. Single ips are trusted as networks with one address.
+func (p *Proxy) TrustedNetworks() ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(p.TrustedProxies))
+	for _, cidr := range p.TrustedProxies {
+		network, err := parseNetwork(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+func parseNetwork(cidr string) (*net.IPNet, error) {
+	if ip := net.ParseIP(cidr); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	return network, err
 }
 
 type Auth struct {
-	SessionTtl int    `mapstructure:"session_ttl"`
-	SigningKey string `mapstructure:"signing_key"`
+	SessionTtl int `mapstructure:"session_ttl"`
+
+	// Primary key, used to sign new sessions.
+	SigningKeyId string `mapstructure:"signing_key_id"`
+	SigningKey   string `mapstructure:"signing_key"`
+
+	RetiredSigningKeys []SigningKey `mapstructure:"retired_signing_keys"`
+
+	RateLimit RateLimit `mapstructure:"rate_limit"`
+	Oidc      Oidc      `mapstructure:"oidc"`
+	Proxy     Proxy     `mapstructure:"proxy"`
+}
+
+// AcceptedSigningKeys returns the primary key, followed by the retired keys
+// that are still accepted at the time.
+func (a *Auth) AcceptedSigningKeys(now time.Time) []string {
+	keys := []string{a.SigningKey}
+	for _, key := range a.RetiredSigningKeys {
+		if now.Before(key.AcceptUntil) {
+			keys = append(keys, key.Key)
+		}
+	}
+
+	return keys
 }
 
 type Database struct {
 	Location string `mapstructure:"location"`
 }
 
+type Trash struct {
+	// Deleted transactions, tags and wallets are purged after this many days.
+	RetentionDays int `mapstructure:"retention_days"`
+}
+
+// Retention returns how long deleted entities are kept in the trash.
+func (t *Trash) Retention() time.Duration {
+	return time.Duration(t.RetentionDays) * 24 * time.Hour
+}
+
 type Observability struct {
 	WriteToFile    bool `mapstructure:"write_to_file"`
 	WriteToConsole bool `mapstructure:"write_to_console"`
@@ -40,6 +175,7 @@ type Config struct {
 	API           API           `mapstructure:"api"`
 	Auth          Auth          `mapstructure:"auth"`
 	Database      Database      `mapstructure:"database"`
+	Trash         Trash         `mapstructure:"trash"`
 	Observability Observability `mapstructure:"observability"`
 }
 
@@ -60,6 +196,35 @@ func Load(configFile string) (*Config, error) {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
+	// Defaults for settings that were added after the config file
+	// could have already been written by an older version.
+	v.SetDefault("api.public_url", "")
+	v.SetDefault("auth.signing_key_id", DefaultSigningKeyId)
+	v.SetDefault("auth.retired_signing_keys", []map[string]any{})
+	v.SetDefault("auth.rate_limit.user_free_attempts", 5)
+	v.SetDefault("auth.rate_limit.ip_free_attempts", 20)
+	v.SetDefault("auth.rate_limit.base_lockout", 30)
+	v.SetDefault("auth.rate_limit.max_lockout", 3600)
+	v.SetDefault("auth.rate_limit.reset_after", 86400)
+	v.SetDefault("auth.rate_limit.persist_path", "")
+	v.SetDefault("auth.oidc.enabled", false)
+	v.SetDefault("auth.oidc.name", "SSO")
+	v.SetDefault("auth.oidc.issuer", "")
+	v.SetDefault("auth.oidc.client_id", "")
+	v.SetDefault("auth.oidc.client_secret", "")
+	v.SetDefault("auth.oidc.redirect_url", "")
+	v.SetDefault("auth.oidc.scopes", []string{"openid", "profile", "email"})
+	v.SetDefault("auth.oidc.username_claim", "preferred_username")
+	v.SetDefault("auth.oidc.auto_provision", false)
+	v.SetDefault("auth.oidc.link_by_username", false)
+	v.SetDefault("auth.oidc.groups_claim", "groups")
+	v.SetDefault("auth.oidc.allowed_groups", []string{})
+	v.SetDefault("auth.proxy.enabled", false)
+	v.SetDefault("auth.proxy.header", "Remote-User")
+	v.SetDefault("auth.proxy.trusted_proxies", []string{})
+	v.SetDefault("auth.proxy.auto_create", false)
+	v.SetDefault("trash.retention_days", 30)
+
 	if err := v.ReadInConfig(); err != nil {
 		return nil, err
 	}
@@ -68,11 +233,25 @@ func Load(configFile string) (*Config, error) {
 	err := v.Unmarshal(conf, func(dc *mapstructure.DecoderConfig) {
 		dc.ErrorUnset = true
 		dc.ErrorUnused = true
+		dc.DecodeHook = mapstructure.ComposeDecodeHookFunc(
+			dc.DecodeHook,
+			mapstructure.StringToTimeHookFunc(time.RFC3339),
+		)
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return conf, err
+	err = conf.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s:\n%w", configFile, err)
+	}
+
+	return conf, nil
 }
 
+// WriteDefault writes the default config if the config file doesn't exist yet.
+// The placeholder signing key in the default config is replaced with a random one.
 func WriteDefault(data []byte) error {
 	_, err := os.Stat(defaultConfigFile)
 	if err == nil {
@@ -81,6 +260,12 @@ func WriteDefault(data []byte) error {
 		return err
 	}
 
+	signingKey, err := GenerateSigningKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	data = bytes.ReplaceAll(data, []byte(placeholderSigningKey), []byte(signingKey))
+
 	f, err := os.Create(defaultConfigFile)
 	if err != nil {
 		return err
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+// DefaultSigningKeyId is the id of the signing key in configs that were
+// written before signing keys had ids. Sessions without a key id were
+// signed with this key.
+const DefaultSigningKeyId = "default"
+
+const signingKeyLength = 32
+
+func GenerateSigningKey() (string, error) {
+	keyBytes := make([]byte, signingKeyLength)
+	_, err := rand.Read(keyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(keyBytes), nil
+}
+
+func GenerateSigningKeyId() string {
+	return time.Now().UTC().Format("20060102150405")
+}
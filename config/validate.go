@@ -0,0 +1,226 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	placeholderSigningKey = "<secret>"
+	minSigningKeyLength   = 32
+)
+
+func (c *Config) validate() error {
+	return errors.Join(
+		c.API.validate(),
+		c.Auth.validate(),
+		c.Database.validate(),
+		c.Trash.validate(),
+		c.Observability.validate(),
+	)
+}
+
+func (a *API) validate() error {
+	var errs []error
+
+	if a.ListenAddress == "" {
+		errs = append(errs, errors.New("api.listen_address: must not be empty"))
+	}
+
+	if a.Port < 1 || a.Port > 65535 {
+		errs = append(errs, fmt.Errorf("api.port: %d is not a valid port", a.Port))
+	}
+
+	if a.PublicUrl != "" && !isHttpUrl(a.PublicUrl) {
+		errs = append(errs, fmt.Errorf("api.public_url: %q is not a valid http(s) url", a.PublicUrl))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *Auth) validate() error {
+	var errs []error
+
+	if a.SessionTtl <= 0 {
+		errs = append(errs, errors.New("auth.session_ttl: must be positive"))
+	}
+
+	if a.SigningKeyId == "" {
+		errs = append(errs, errors.New("auth.signing_key_id: must not be empty"))
+	}
+
+	ids := map[string]bool{a.SigningKeyId: true}
+	for i, key := range a.RetiredSigningKeys {
+		field := fmt.Sprintf("auth.retired_signing_keys[%d]", i)
+
+		if key.Id == "" {
+			errs = append(errs, fmt.Errorf("%s.id: must not be empty", field))
+		} else if ids[key.Id] {
+			errs = append(errs, fmt.Errorf("%s.id: duplicate key id %q", field, key.Id))
+		}
+		ids[key.Id] = true
+
+		if key.AcceptUntil.IsZero() {
+			errs = append(errs, fmt.Errorf("%s.accept_until: must be set", field))
+		}
+	}
+
+	errs = append(errs, a.RateLimit.validate())
+	errs = append(errs, a.Oidc.validate())
+	errs = append(errs, a.Proxy.validate())
+
+	return errors.Join(errs...)
+}
+
+// ValidateSigningKeys checks that the keys are secret. It isn't part of loading the config,
+// so that commands like generate-signing-key still work with a config that has a weak key.
+func (a *Auth) ValidateSigningKeys() error {
+	var errs []error
+
+	if !a.IsSigningKeySecure() {
+		if a.SigningKey == placeholderSigningKey {
+			errs = append(errs, errors.New("auth.signing_key: must be replaced with a random secret, anyone can forge sessions signed with the placeholder"))
+		} else {
+			errs = append(errs, fmt.Errorf("auth.signing_key: must be at least %d characters long", minSigningKeyLength))
+		}
+	}
+
+	for i, key := range a.RetiredSigningKeys {
+		if key.Key == "" || key.Key == placeholderSigningKey {
+			errs = append(errs, fmt.Errorf("auth.retired_signing_keys[%d].key: must be a random secret", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsSigningKeySecure returns false for the placeholder and keys that are too short.
+func (a *Auth) IsSigningKeySecure() bool {
+	return a.SigningKey != placeholderSigningKey && len(a.SigningKey) >= minSigningKeyLength
+}
+
+func (r *RateLimit) validate() error {
+	var errs []error
+
+	if r.UserFreeAttempts <= 0 {
+		errs = append(errs, errors.New("auth.rate_limit.user_free_attempts: must be positive"))
+	}
+
+	if r.IpFreeAttempts <= 0 {
+		errs = append(errs, errors.New("auth.rate_limit.ip_free_attempts: must be positive"))
+	}
+
+	if r.BaseLockout <= 0 {
+		errs = append(errs, errors.New("auth.rate_limit.base_lockout: must be positive"))
+	}
+
+	if r.MaxLockout < r.BaseLockout {
+		errs = append(errs, errors.New("auth.rate_limit.max_lockout: must not be smaller than base_lockout"))
+	}
+
+	if r.ResetAfter <= 0 {
+		errs = append(errs, errors.New("auth.rate_limit.reset_after: must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (o *Oidc) validate() error {
+	if !o.Enabled {
+		return nil
+	}
+
+	var errs []error
+
+	if !isHttpUrl(o.Issuer) {
+		errs = append(errs, fmt.Errorf("auth.oidc.issuer: %q is not a valid http(s) url", o.Issuer))
+	}
+
+	if o.ClientId == "" {
+		errs = append(errs, errors.New("auth.oidc.client_id: must not be empty"))
+	}
+
+	if o.RedirectUrl != "" && !isHttpUrl(o.RedirectUrl) {
+		errs = append(errs, fmt.Errorf("auth.oidc.redirect_url: %q is not a valid http(s) url", o.RedirectUrl))
+	}
+
+	if o.UsernameClaim == "" {
+		errs = append(errs, errors.New("auth.oidc.username_claim: must not be empty"))
+	}
+
+	if len(o.AllowedGroups) > 0 && o.GroupsClaim == "" {
+		errs = append(errs, errors.New("auth.oidc.groups_claim: must not be empty when allowed_groups is set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *Proxy) validate() error {
+	var errs []error
+
+	// Trusted proxies are used for client ips even without proxy auth.
+	for i, cidr := range p.TrustedProxies {
+		_, err := parseNetwork(cidr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("auth.proxy.trusted_proxies[%d]: %q is not a valid ip or cidr", i, cidr))
+		}
+	}
+
+	if !p.Enabled {
+		return errors.Join(errs...)
+	}
+
+	if p.Header == "" {
+		errs = append(errs, errors.New("auth.proxy.header: must not be empty"))
+	}
+
+	if len(p.TrustedProxies) == 0 {
+		errs = append(errs, errors.New("auth.proxy.trusted_proxies: must not be empty, anyone could set the header otherwise"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *Database) validate() error {
+	if d.Location == "" {
+		return errors.New("database.location: must not be empty")
+	}
+
+	return nil
+}
+
+func (t *Trash) validate() error {
+	if t.RetentionDays <= 0 {
+		return errors.New("trash.retention_days: must be positive")
+	}
+
+	return nil
+}
+
+func (o *Observability) validate() error {
+	if !o.WriteToFile {
+		return nil
+	}
+
+	var errs []error
+
+	if o.Path == "" {
+		errs = append(errs, errors.New("observability.path: must not be empty when writing logs to file"))
+	}
+
+	if o.MaxSize <= 0 {
+		errs = append(errs, errors.New("observability.max_size: must be positive"))
+	}
+
+	if o.MaxBackups < 0 {
+		errs = append(errs, errors.New("observability.max_backups: must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func isHttpUrl(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
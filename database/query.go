@@ -0,0 +1,21 @@
+package database
+
+import (
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+// InRange limits the query to rows with the date column on the days in the range.
+func InRange(builder sq.SelectBuilder, column string, dateRange models.DateRange) sq.SelectBuilder {
+	// Dates are stored as text starting with the day, so days compare as strings.
+	if !dateRange.From.IsZero() {
+		builder = builder.Where(column+" >= ?", dateRange.From.Format(time.DateOnly))
+	}
+	if !dateRange.To.IsZero() {
+		builder = builder.Where(column+" < ?", dateRange.To.AddDate(0, 0, 1).Format(time.DateOnly))
+	}
+
+	return builder
+}
@@ -0,0 +1,316 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+type AuthService interface {
+	NewTwoFactorSetup(ctx context.Context, user *models.User) (*auth.TwoFactorSetup, error)
+	PendingTwoFactorSetup(ctx context.Context, user *models.User) (*auth.TwoFactorSetup, error)
+	EnableTwoFactor(ctx context.Context, userId int, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userId int, code string) error
+
+	ChangePassword(ctx context.Context, ip string, userId int, currentPassword, newPassword string) (*models.Session, error)
+	SetSessionCookie(w http.ResponseWriter, session *models.Session) bool
+
+	CreateInvite(ctx context.Context, createdBy, walletId *int) (string, error)
+	InviteUrl(r *http.Request, token string) string
+}
+
+type UserRepository interface {
+	GetById(ctx context.Context, id int) (*models.UserCredentials, error)
+	CountRecoveryCodes(ctx context.Context, userId int) (int, error)
+}
+
+type WalletRepository interface {
+	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
+}
+
+type Account struct {
+	authService      AuthService
+	userRepository   UserRepository
+	walletRepository WalletRepository
+
+	log *slog.Logger
+}
+
+func New(
+	authService AuthService,
+	userRepository UserRepository,
+	walletRepository WalletRepository,
+	log *slog.Logger,
+) *Account {
+	return &Account{
+		authService:      authService,
+		userRepository:   userRepository,
+		walletRepository: walletRepository,
+
+		log: log,
+	}
+}
+
+func (a *Account) Mount(router chi.Router) {
+	group := chi.NewRouter()
+	group.Use(auth.RequiredMiddleware)
+
+	group.Get("/", a.account)
+	group.Post("/password", a.changePassword)
+	group.Post("/invites", a.createInvite)
+	group.Post("/two-factor/setup", a.setupTwoFactor)
+	group.Post("/two-factor/enable", a.enableTwoFactor)
+	group.Post("/two-factor/disable", a.disableTwoFactor)
+
+	router.Mount("/account", group)
+}
+
+func (a *Account) navbar(ctx context.Context, user *models.User) (models.Navbar, error) {
+	wallets, err := a.walletRepository.ForUser(ctx, user.Id)
+	if err != nil {
+		return models.Navbar{}, err
+	}
+
+	return models.Navbar{
+		Wallets:  wallets,
+		Username: user.Username,
+		IsAdmin:  user.IsAdmin,
+		Title:    "Šparovec | Account",
+	}, nil
+}
+
+func (a *Account) account(w http.ResponseWriter, r *http.Request) {
+	a.renderAccount(w, r, accountViewData{
+		PasswordChanged: r.URL.Query().Get("password") == "changed",
+	})
+}
+
+func (a *Account) changePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	newPassword := r.FormValue("new_password")
+	if newPassword != r.FormValue("confirm_password") {
+		a.renderAccount(w, r, accountViewData{PasswordError: "Passwords don't match"})
+		return
+	}
+
+	session, err := a.authService.ChangePassword(ctx, auth.ClientIp(r), user.Id, r.FormValue("current_password"), newPassword)
+
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		a.renderAccount(w, r, accountViewData{PasswordError: invalidForm.Message})
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	a.log.InfoContext(ctx, "Changed password", "user_id", user.Id)
+
+	// Other sessions were invalidated together with the current one,
+	// so the current user gets a new session.
+	ok := a.authService.SetSessionCookie(w, session)
+	if !ok {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/account?password=changed", http.StatusSeeOther)
+}
+
+func (a *Account) createInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	var walletId *int
+	if r.FormValue("wallet_id") != "" {
+		id, err := strconv.Atoi(r.FormValue("wallet_id"))
+		if err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		if !a.isOwner(ctx, w, id, user.Id) {
+			return
+		}
+
+		walletId = &id
+	}
+
+	token, err := a.authService.CreateInvite(ctx, &user.Id, walletId)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	a.log.InfoContext(ctx, "Created invite", "user_id", user.Id, "wallet_id", r.FormValue("wallet_id"))
+	a.renderAccount(w, r, accountViewData{InviteUrl: a.authService.InviteUrl(r, token)})
+}
+
+// isOwner checks that the user can invite others to the wallet.
+func (a *Account) isOwner(ctx context.Context, w http.ResponseWriter, walletId, userId int) bool {
+	wallets, err := a.walletRepository.ForUser(ctx, userId)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+
+	for _, wallet := range wallets {
+		if wallet.Id == walletId && wallet.Role == models.RoleOwner {
+			return true
+		}
+	}
+
+	http.Error(w, "Forbidden", http.StatusForbidden)
+	return false
+}
+
+// renderAccount renders the account page. Form errors and messages
+// are taken from data, the rest is loaded here.
+func (a *Account) renderAccount(w http.ResponseWriter, r *http.Request, data accountViewData) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	navbar, err := a.navbar(ctx, user)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	creds, err := a.userRepository.GetById(ctx, user.Id)
+	if err != nil || creds == nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	recoveryCodes, err := a.userRepository.CountRecoveryCodes(ctx, user.Id)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to count recovery codes", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data.Navbar = navbar
+	data.HasPassword = creds.HasPassword()
+	data.TwoFactor = creds.HasTwoFactor()
+	data.RecoveryCodes = recoveryCodes
+
+	view := accountView(data)
+	err = view.Render(ctx, w)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to render account view", "error", err)
+	}
+}
+
+func (a *Account) setupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	setup, err := a.authService.NewTwoFactorSetup(ctx, user)
+
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		a.renderAccount(w, r, accountViewData{TwoFactorError: invalidForm.Message})
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	a.log.InfoContext(ctx, "Started two-factor setup", "user_id", user.Id)
+	a.renderSetup(w, r, setup, "")
+}
+
+func (a *Account) enableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	codes, err := a.authService.EnableTwoFactor(ctx, user.Id, r.FormValue("code"))
+
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		setup, err := a.authService.PendingTwoFactorSetup(ctx, user)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		// Without a pending setup there is no code to retry.
+		if setup == nil {
+			a.renderAccount(w, r, accountViewData{TwoFactorError: invalidForm.Message})
+			return
+		}
+
+		a.renderSetup(w, r, setup, invalidForm.Message)
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	a.log.InfoContext(ctx, "Enabled two-factor authentication", "user_id", user.Id)
+
+	navbar, err := a.navbar(ctx, user)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	view := recoveryCodesView(navbar, codes)
+	err = view.Render(ctx, w)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to render recovery codes view", "error", err)
+	}
+}
+
+func (a *Account) disableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	err := a.authService.DisableTwoFactor(ctx, user.Id, r.FormValue("code"))
+
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		a.renderAccount(w, r, accountViewData{TwoFactorError: invalidForm.Message})
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	a.log.InfoContext(ctx, "Disabled two-factor authentication", "user_id", user.Id)
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
+
+func (a *Account) renderSetup(w http.ResponseWriter, r *http.Request, setup *auth.TwoFactorSetup, formError string) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	navbar, err := a.navbar(ctx, user)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	view := setupView(setupViewData{
+		Navbar: navbar,
+		Setup:  setup,
+		Error:  formError,
+	})
+	err = view.Render(ctx, w)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to render setup view", "error", err)
+	}
+}
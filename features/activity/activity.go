@@ -0,0 +1,251 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"github.com/viddrobnic/sparovec/features"
+	"github.com/viddrobnic/sparovec/features/audit"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+type Repository interface {
+	List(ctx context.Context, req *models.AuditListRequest) ([]*models.AuditEntry, int, error)
+	Actors(ctx context.Context, walletId int) ([]*models.AuditActor, error)
+}
+
+type WalletRepository interface {
+	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
+	Role(ctx context.Context, walletId, userId int) (models.Role, error)
+}
+
+type Activity struct {
+	repository       Repository
+	walletRepository WalletRepository
+
+	log *slog.Logger
+}
+
+func New(
+	repository Repository,
+	walletRepository WalletRepository,
+	log *slog.Logger,
+) *Activity {
+	return &Activity{
+		repository:       repository,
+		walletRepository: walletRepository,
+
+		log: log,
+	}
+}
+
+func (a *Activity) Mount(router chi.Router) {
+	group := chi.NewRouter()
+	group.Use(auth.RequiredMiddleware)
+
+	group.Get("/", a.activity)
+
+	router.Mount("/wallets/{walletId}/activity", group)
+}
+
+func (a *Activity) activity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+	walletId := features.GetWalletId(r)
+
+	role, err := a.walletRepository.Role(ctx, walletId, user.Id)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get wallet role", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !role.Includes(models.RoleViewer) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	userId, _ := strconv.Atoi(query.Get("user"))
+	entity := models.AuditEntity(query.Get("entity"))
+	if !entity.IsValid() {
+		entity = ""
+	}
+
+	req := &models.AuditListRequest{
+		WalletId: walletId,
+		UserId:   userId,
+		Entity:   entity,
+		Page:     models.NewPage(page, 0),
+	}
+
+	entries, count, err := a.repository.List(ctx, req)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to list audit log", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	actors, err := a.repository.Actors(ctx, walletId)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to list audit log actors", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	rendered := make([]*entryRender, len(entries))
+	for i, entry := range entries {
+		rendered[i], err = renderEntry(entry)
+		if err != nil {
+			a.log.ErrorContext(ctx, "Failed to render audit log entry", "error", err, "entry_id", entry.Id)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	wallets, err := a.walletRepository.ForUser(ctx, user.Id)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	pages := int(math.Ceil(float64(count) / float64(req.Page.PageSize)))
+
+	navbar := models.Navbar{
+		SelectedWalletId: walletId,
+		Wallets:          wallets,
+		Username:         user.Username,
+		IsAdmin:          user.IsAdmin,
+		Title:            "Šparovec | Activity",
+	}
+
+	view := activityView(activityViewData{
+		navbar:          navbar,
+		walletId:        walletId,
+		entries:         rendered,
+		actors:          actors,
+		selectedUserId:  userId,
+		selectedEntity:  entity,
+		currentPage:     strconv.Itoa(req.Page.Page),
+		totalPages:      strconv.Itoa(pages),
+		previousPageUrl: pageUrl(walletId, query, req.Page.Page-1, pages),
+		nextPageUrl:     pageUrl(walletId, query, req.Page.Page+1, pages),
+	})
+	err = view.Render(ctx, w)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to render view", "error", err)
+	}
+}
+
+// pageUrl returns the url of the page with the current filters, or an empty url if the page doesn't exist.
+func pageUrl(walletId int, query url.Values, page, pages int) templ.SafeURL {
+	if page < 1 || page > pages {
+		return ""
+	}
+
+	query.Set("page", strconv.Itoa(page))
+	return templ.SafeURL(fmt.Sprintf("/wallets/%d/activity?%s", walletId, query.Encode()))
+}
+
+type fieldChange struct {
+	Name   string
+	Before string
+	After  string
+}
+
+type entryRender struct {
+	CreatedAt string
+	Actor     string
+	Action    models.AuditAction
+	Summary   string
+	Changes   []fieldChange
+}
+
+var actionNames = map[models.AuditAction]string{
+	models.AuditCreate:  "Created",
+	models.AuditUpdate:  "Updated",
+	models.AuditDelete:  "Deleted",
+	models.AuditRestore: "Restored",
+}
+
+func renderEntry(entry *models.AuditEntry) (*entryRender, error) {
+	before, err := audit.DecodeFields(entry.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := audit.DecodeFields(entry.After)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := "Command line"
+	if entry.Username.Valid {
+		actor = entry.Username.String
+	}
+
+	return &entryRender{
+		CreatedAt: entry.CreatedAt.Format(time.DateTime),
+		Actor:     actor,
+		Action:    entry.Action,
+		Summary:   summary(entry, before, after),
+		Changes:   fieldChanges(before, after),
+	}, nil
+}
+
+// summary describes the change, like "Created tag Groceries".
+func summary(entry *models.AuditEntry, before, after []models.AuditField) string {
+	fields := after
+	if fields == nil {
+		fields = before
+	}
+
+	// Members and invitations are named by the user, everything else by the name.
+	for _, field := range fields {
+		if field.Name == "Name" || field.Name == "User" {
+			return fmt.Sprintf("%s %s %s", actionNames[entry.Action], entry.Entity, field.Value)
+		}
+	}
+
+	return fmt.Sprintf("%s %s", actionNames[entry.Action], entry.Entity)
+}
+
+// fieldChanges lists all fields of created and deleted entities,
+// and only the changed fields of updated entities.
+func fieldChanges(before, after []models.AuditField) []fieldChange {
+	beforeValues := map[string]string{}
+	for _, field := range before {
+		beforeValues[field.Name] = field.Value
+	}
+
+	changes := []fieldChange{}
+	if after == nil {
+		for _, field := range before {
+			changes = append(changes, fieldChange{Name: field.Name, Before: field.Value})
+		}
+
+		return changes
+	}
+
+	for _, field := range after {
+		previous, ok := beforeValues[field.Name]
+		if before != nil && ok && previous == field.Value {
+			continue
+		}
+
+		changes = append(changes, fieldChange{Name: field.Name, Before: previous, After: field.Value})
+	}
+
+	return changes
+}
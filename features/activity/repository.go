@@ -0,0 +1,77 @@
+package activity
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+type RepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *RepositoryImpl {
+	return &RepositoryImpl{db: db}
+}
+
+// List returns a page of the audit log of the wallet, newest first, and the total number of entries.
+func (a *RepositoryImpl) List(ctx context.Context, req *models.AuditListRequest) ([]*models.AuditEntry, int, error) {
+	builder := sq.Select("*").From("audit_log").Where("wallet_id = ?", req.WalletId)
+	if req.UserId != 0 {
+		builder = builder.Where("user_id = ?", req.UserId)
+	}
+	if req.Entity != "" {
+		builder = builder.Where("entity = ?", req.Entity)
+	}
+
+	countBuilder := sq.Select("COUNT(*)").FromSelect(builder, "audit_log")
+
+	builder = builder.
+		OrderBy("created_at DESC", "id DESC").
+		Offset(uint64(req.Page.Offset())).
+		Limit(uint64(req.Page.Limit()))
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := []*models.AuditEntry{}
+	err = a.db.SelectContext(ctx, &entries, stmt, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	countStmt, countArgs, err := countBuilder.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var count int
+	err = a.db.GetContext(ctx, &count, countStmt, countArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, count, nil
+}
+
+// Actors returns the users that changed something in the wallet, with their current usernames.
+func (a *RepositoryImpl) Actors(ctx context.Context, walletId int) ([]*models.AuditActor, error) {
+	builder := sq.Select("DISTINCT a.user_id", "u.username").
+		From("audit_log a").
+		InnerJoin("users u ON u.id = a.user_id").
+		Where("a.wallet_id = ?", walletId).
+		OrderBy("u.username")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	actors := []*models.AuditActor{}
+	err = a.db.SelectContext(ctx, &actors, stmt, args...)
+	return actors, err
+}
@@ -0,0 +1,264 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/viddrobnic/sparovec/config"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/models"
+	"github.com/viddrobnic/sparovec/observability"
+)
+
+const nrLogEntries = 200
+
+type AuthService interface {
+	ListUsers(ctx context.Context) ([]*models.UserCredentials, error)
+	CreateUser(ctx context.Context, username, password string, isAdmin bool) (*models.User, error)
+	SetUserDisabled(ctx context.Context, username string, disabled bool) error
+	ResetTwoFactor(ctx context.Context, username string) error
+	IssuePasswordReset(ctx context.Context, username string) (string, error)
+	PasswordResetUrl(r *http.Request, token string) string
+}
+
+type Repository interface {
+	Stats(ctx context.Context) (*models.InstanceStats, error)
+}
+
+type WalletRepository interface {
+	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
+}
+
+type Admin struct {
+	authService      AuthService
+	repository       Repository
+	walletRepository WalletRepository
+
+	conf *config.Config
+	log  *slog.Logger
+}
+
+func New(
+	authService AuthService,
+	repository Repository,
+	walletRepository WalletRepository,
+	conf *config.Config,
+	log *slog.Logger,
+) *Admin {
+	return &Admin{
+		authService:      authService,
+		repository:       repository,
+		walletRepository: walletRepository,
+
+		conf: conf,
+		log:  log,
+	}
+}
+
+func (a *Admin) Mount(router chi.Router) {
+	group := chi.NewRouter()
+	group.Use(auth.RequiredMiddleware, auth.AdminMiddleware)
+
+	group.Get("/", a.admin)
+	group.Post("/users", a.createUser)
+	group.Post("/users/disable", a.disableUser)
+	group.Post("/users/enable", a.enableUser)
+	group.Post("/users/reset-2fa", a.resetTwoFactor)
+	group.Post("/users/reset-password", a.resetPassword)
+	group.Get("/logs", a.logs)
+
+	router.Mount("/admin", group)
+}
+
+func (a *Admin) navbar(ctx context.Context, user *models.User, title string) (models.Navbar, error) {
+	wallets, err := a.walletRepository.ForUser(ctx, user.Id)
+	if err != nil {
+		return models.Navbar{}, err
+	}
+
+	return models.Navbar{
+		Wallets:  wallets,
+		Username: user.Username,
+		IsAdmin:  user.IsAdmin,
+		Title:    title,
+	}, nil
+}
+
+func (a *Admin) admin(w http.ResponseWriter, r *http.Request) {
+	a.renderAdmin(w, r, adminViewData{})
+}
+
+func (a *Admin) createUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := r.FormValue("username")
+
+	user, err := a.authService.CreateUser(ctx, username, r.FormValue("password"), r.FormValue("is_admin") == "on")
+	if a.handleError(w, r, err) {
+		return
+	}
+
+	a.log.InfoContext(ctx, "Created user", "user_id", user.Id, "by", auth.GetUser(r).Id)
+	a.renderAdmin(w, r, adminViewData{Message: fmt.Sprintf("Created user %s.", username)})
+}
+
+func (a *Admin) disableUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := r.FormValue("username")
+
+	if username == auth.GetUser(r).Username {
+		a.renderAdmin(w, r, adminViewData{Error: "You can't disable yourself."})
+		return
+	}
+
+	err := a.authService.SetUserDisabled(ctx, username, true)
+	if a.handleError(w, r, err) {
+		return
+	}
+
+	a.log.InfoContext(ctx, "Disabled user", "username", username, "by", auth.GetUser(r).Id)
+	a.renderAdmin(w, r, adminViewData{Message: fmt.Sprintf("Disabled %s and signed them out.", username)})
+}
+
+func (a *Admin) enableUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := r.FormValue("username")
+
+	err := a.authService.SetUserDisabled(ctx, username, false)
+	if a.handleError(w, r, err) {
+		return
+	}
+
+	a.log.InfoContext(ctx, "Enabled user", "username", username, "by", auth.GetUser(r).Id)
+	a.renderAdmin(w, r, adminViewData{Message: fmt.Sprintf("Enabled %s.", username)})
+}
+
+func (a *Admin) resetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := r.FormValue("username")
+
+	err := a.authService.ResetTwoFactor(ctx, username)
+	if a.handleError(w, r, err) {
+		return
+	}
+
+	a.log.InfoContext(ctx, "Reset two-factor authentication", "username", username, "by", auth.GetUser(r).Id)
+	a.renderAdmin(w, r, adminViewData{
+		Message: fmt.Sprintf("Disabled two-factor authentication for %s.", username),
+	})
+}
+
+func (a *Admin) resetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := r.FormValue("username")
+
+	token, err := a.authService.IssuePasswordReset(ctx, username)
+	if a.handleError(w, r, err) {
+		return
+	}
+
+	a.log.InfoContext(ctx, "Issued password reset", "username", username, "by", auth.GetUser(r).Id)
+	a.renderAdmin(w, r, adminViewData{
+		Message:  fmt.Sprintf("Send this link to %s, so they can choose a new password:", username),
+		ResetUrl: a.authService.PasswordResetUrl(r, token),
+	})
+}
+
+// handleError renders the admin page with the error message for form errors
+// and responds with an internal server error otherwise. It returns true if there was an error.
+func (a *Admin) handleError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var invalidForm *models.ErrInvalidForm
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &invalidForm):
+		a.renderAdmin(w, r, adminViewData{Error: invalidForm.Message})
+	case errors.Is(err, models.ErrNotFound):
+		a.renderAdmin(w, r, adminViewData{Error: "User not found."})
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+
+	return true
+}
+
+// renderAdmin renders the admin page. Messages are taken from data, the rest is loaded here.
+func (a *Admin) renderAdmin(w http.ResponseWriter, r *http.Request, data adminViewData) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	navbar, err := a.navbar(ctx, user, "Šparovec | Admin")
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	stats, err := a.repository.Stats(ctx)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get instance stats", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	users, err := a.authService.ListUsers(ctx)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data.Navbar = navbar
+	data.Stats = stats
+	data.Users = users
+	data.CurrentUserId = user.Id
+
+	view := adminView(data)
+	err = view.Render(ctx, w)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to render admin view", "error", err)
+	}
+}
+
+func (a *Admin) logs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	navbar, err := a.navbar(ctx, user, "Šparovec | Logs")
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	level := slog.LevelInfo
+	if r.URL.Query().Has("level") {
+		err = level.UnmarshalText([]byte(r.URL.Query().Get("level")))
+		if err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	data := logsViewData{
+		Navbar: navbar,
+		Level:  level,
+	}
+
+	data.Entries, err = observability.ReadRecent(a.conf, nrLogEntries, level)
+	if errors.Is(err, observability.ErrFileLoggingDisabled) {
+		data.Error = "Logging to file is disabled. Enable write_to_file in the [observability] section of the config."
+	} else if err != nil {
+		a.log.ErrorContext(ctx, "Failed to read logs", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	view := logsView(data)
+	err = view.Render(ctx, w)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to render logs view", "error", err)
+	}
+}
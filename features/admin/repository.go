@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+type RepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *RepositoryImpl {
+	return &RepositoryImpl{db: db}
+}
+
+func (r *RepositoryImpl) Stats(ctx context.Context) (*models.InstanceStats, error) {
+	stats := &models.InstanceStats{}
+
+	// Items in the trash and items of wallets in the trash aren't counted.
+	activeWallets := sq.Expr("wallet_id IN (SELECT id FROM wallets WHERE deleted_at IS NULL)")
+	counts := []struct {
+		table string
+		where []sq.Sqlizer
+		dest  *int
+	}{
+		{"users", nil, &stats.Users},
+		{"wallets", []sq.Sqlizer{sq.Eq{"deleted_at": nil}}, &stats.Wallets},
+		{"transactions", []sq.Sqlizer{sq.Eq{"deleted_at": nil}, activeWallets}, &stats.Transactions},
+		{"tags", []sq.Sqlizer{sq.Eq{"deleted_at": nil}, activeWallets}, &stats.Tags},
+	}
+	for _, count := range counts {
+		builder := sq.Select("COUNT(*)").From(count.table)
+		for _, where := range count.where {
+			builder = builder.Where(where)
+		}
+
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		err = r.db.GetContext(ctx, count.dest, stmt, args...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Size of the database, without the write ahead log.
+	err := r.db.GetContext(
+		ctx,
+		&stats.DatabaseSize,
+		"SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,272 @@
+// Package audit records changes of wallets. Repositories record changes in the
+// same database transaction as the change itself, so that no change is missed.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+// Change of an entity. Before is nil for creates and after is nil for deletes.
+type Change struct {
+	WalletId int
+	Entity   models.AuditEntity
+	EntityId int
+	Action   models.AuditAction
+	Before   []models.AuditField
+	After    []models.AuditField
+}
+
+// Record writes the change to the audit log. The acting user is taken from the context.
+// Updates that don't change anything aren't recorded.
+func Record(ctx context.Context, db sqlx.ExecerContext, change Change) error {
+	if change.Action == models.AuditUpdate && reflect.DeepEqual(change.Before, change.After) {
+		return nil
+	}
+
+	before, err := encodeFields(change.Before)
+	if err != nil {
+		return err
+	}
+
+	after, err := encodeFields(change.After)
+	if err != nil {
+		return err
+	}
+
+	var userId sql.NullInt64
+	var username sql.NullString
+	if user := models.UserFromContext(ctx); user != nil {
+		userId = sql.NullInt64{Int64: int64(user.Id), Valid: true}
+		username = sql.NullString{String: user.Username, Valid: true}
+	}
+
+	builder := sq.Insert("audit_log").
+		Columns("wallet_id", "user_id", "username", "entity", "entity_id", "action", "before", "after").
+		Values(change.WalletId, userId, username, change.Entity, change.EntityId, change.Action, before, after)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+// RecordChange records the change of the entity. The fields after
+// the change are read from the database.
+func RecordChange(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	walletId int,
+	entity models.AuditEntity,
+	entityId int,
+	action models.AuditAction,
+	before []models.AuditField,
+) error {
+	var after []models.AuditField
+	if action != models.AuditDelete {
+		var err error
+		after, err = Fields(ctx, tx, walletId, entity, entityId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return Record(ctx, tx, Change{
+		WalletId: walletId,
+		Entity:   entity,
+		EntityId: entityId,
+		Action:   action,
+		Before:   before,
+		After:    after,
+	})
+}
+
+// Fields returns the current fields of the entity, or nil if it doesn't exist.
+func Fields(ctx context.Context, db sqlx.QueryerContext, walletId int, entity models.AuditEntity, entityId int) ([]models.AuditField, error) {
+	switch entity {
+	case models.AuditTransaction:
+		return TransactionFields(ctx, db, walletId, entityId)
+	case models.AuditTag:
+		return TagFields(ctx, db, walletId, entityId)
+	case models.AuditMember:
+		return MemberFields(ctx, db, walletId, entityId)
+	case models.AuditInvitation:
+		return InvitationFields(ctx, db, walletId, entityId)
+	case models.AuditWallet:
+		return WalletFields(ctx, db, walletId)
+	default:
+		return nil, fmt.Errorf("unknown audit entity %q", entity)
+	}
+}
+
+func encodeFields(fields []models.AuditField) (sql.NullString, error) {
+	if fields == nil {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// DecodeFields decodes fields stored by Record.
+func DecodeFields(value sql.NullString) ([]models.AuditField, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+
+	fields := []models.AuditField{}
+	err := json.Unmarshal([]byte(value.String), &fields)
+	return fields, err
+}
+
+// TransactionFields returns the current fields of the transaction,
+// or nil if the transaction doesn't exist.
+func TransactionFields(ctx context.Context, db sqlx.QueryerContext, walletId, transactionId int) ([]models.AuditField, error) {
+	builder := sq.Select("t.name", "t.value", "t.created_at", "tg.name AS tag").
+		From("transactions t").
+		LeftJoin("tags tg ON tg.id = t.tag_id").
+		Where(sq.Eq{"t.id": transactionId, "t.wallet_id": walletId})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var transaction struct {
+		Name      string
+		Value     int
+		CreatedAt time.Time `db:"created_at"`
+		Tag       sql.NullString
+	}
+	err = sqlx.GetContext(ctx, db, &transaction, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []models.AuditField{
+		{Name: "Name", Value: transaction.Name},
+		{Name: "Value", Value: models.FormatCurrency(transaction.Value)},
+		{Name: "Tag", Value: transaction.Tag.String},
+		{Name: "Date", Value: transaction.CreatedAt.Format("02. 01. 2006")},
+	}, nil
+}
+
+// TagFields returns the current fields of the tag, or nil if the tag doesn't exist.
+func TagFields(ctx context.Context, db sqlx.QueryerContext, walletId, tagId int) ([]models.AuditField, error) {
+	builder := sq.Select("name", "color", "icon", "description").
+		From("tags").
+		Where(sq.Eq{"id": tagId, "wallet_id": walletId})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var tag struct {
+		Name        string
+		Color       string
+		Icon        string
+		Description string
+	}
+	err = sqlx.GetContext(ctx, db, &tag, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []models.AuditField{
+		{Name: "Name", Value: tag.Name},
+		{Name: "Color", Value: tag.Color},
+		{Name: "Icon", Value: tag.Icon},
+		{Name: "Description", Value: tag.Description},
+	}, nil
+}
+
+// MemberFields returns the current fields of the member, or nil if the user is not a member.
+func MemberFields(ctx context.Context, db sqlx.QueryerContext, walletId, userId int) ([]models.AuditField, error) {
+	builder := sq.Select("u.username", "wu.role").
+		From("wallet_users wu").
+		InnerJoin("users u ON u.id = wu.user_id").
+		Where(sq.Eq{"wu.wallet_id": walletId, "wu.user_id": userId})
+
+	return userRoleFields(ctx, db, builder)
+}
+
+// InvitationFields returns the current fields of the invitation,
+// or nil if the invitation doesn't exist.
+func InvitationFields(ctx context.Context, db sqlx.QueryerContext, walletId, invitationId int) ([]models.AuditField, error) {
+	builder := sq.Select("u.username", "i.role").
+		From("wallet_invitations i").
+		InnerJoin("users u ON u.id = i.user_id").
+		Where(sq.Eq{"i.wallet_id": walletId, "i.id": invitationId})
+
+	return userRoleFields(ctx, db, builder)
+}
+
+func userRoleFields(ctx context.Context, db sqlx.QueryerContext, builder sq.SelectBuilder) ([]models.AuditField, error) {
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var member struct {
+		Username string
+		Role     models.Role
+	}
+	err = sqlx.GetContext(ctx, db, &member, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []models.AuditField{
+		{Name: "User", Value: member.Username},
+		{Name: "Role", Value: string(member.Role)},
+	}, nil
+}
+
+// WalletFields returns the current settings of the wallet, or nil if the wallet doesn't exist.
+func WalletFields(ctx context.Context, db sqlx.QueryerContext, walletId int) ([]models.AuditField, error) {
+	builder := sq.Select("name", "period_start_day").From("wallets").Where("id = ?", walletId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var wallet struct {
+		Name           string
+		PeriodStartDay int `db:"period_start_day"`
+	}
+	err = sqlx.GetContext(ctx, db, &wallet, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []models.AuditField{
+		{Name: "Name", Value: wallet.Name},
+		{Name: "Period start day", Value: strconv.Itoa(wallet.PeriodStartDay)},
+	}, nil
+}
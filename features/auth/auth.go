@@ -2,7 +2,10 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sagikazarmark/slog-shim"
@@ -12,19 +15,57 @@ import (
 
 type Repository interface {
 	GetByUsername(ctx context.Context, username string) (*models.UserCredentials, error)
-	Insert(ctx context.Context, username, password, salt string) (*models.UserCredentials, error)
+	GetById(ctx context.Context, id int) (*models.UserCredentials, error)
+	Insert(ctx context.Context, username, password, salt string, isAdmin bool) (*models.UserCredentials, error)
+
+	GetByOidcSubject(ctx context.Context, issuer, subject string) (*models.UserCredentials, error)
+	InsertOidc(ctx context.Context, username, issuer, subject string) (*models.UserCredentials, error)
+	SetOidcSubject(ctx context.Context, userId int, issuer, subject string) error
+	InsertWithoutPassword(ctx context.Context, username string) (*models.UserCredentials, error)
+
+	SetTotpPendingSecret(ctx context.Context, userId int, secret string) error
+	EnableTwoFactor(ctx context.Context, userId int, secret string, counter int64, codeHashes []string) (bool, error)
+	DisableTwoFactor(ctx context.Context, userId int) error
+	UseTotpCounter(ctx context.Context, userId int, counter int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userId int) (int, error)
+
+	UpdatePassword(ctx context.Context, userId int, password, salt string) (int, error)
+	InsertPasswordReset(ctx context.Context, userId int, tokenHash string, ttl time.Duration) error
+	IsPasswordResetValid(ctx context.Context, tokenHash string) (bool, error)
+	ResetPassword(ctx context.Context, tokenHash, password, salt string) (bool, error)
+
+	List(ctx context.Context) ([]*models.UserCredentials, error)
+	UpdateUsername(ctx context.Context, userId int, username string) error
+	Delete(ctx context.Context, userId int) (int, error)
+	SetDisabled(ctx context.Context, userId int, disabled bool) error
+	SetAdmin(ctx context.Context, userId int, isAdmin bool) error
+
+	InsertInvite(ctx context.Context, tokenHash string, createdBy, walletId *int, ttl time.Duration) error
+	IsInviteValid(ctx context.Context, tokenHash string) (bool, error)
+	SignUp(ctx context.Context, tokenHash, username, password, salt string) (*models.UserCredentials, error)
+}
+
+type Limiter interface {
+	LockedFor(ip, username string) time.Duration
+	Fail(ctx context.Context, ip, username string)
+	Succeed(username string)
 }
 
 type Auth struct {
 	repository Repository
+	limiter    Limiter
+	oidc       *oidcProvider
 
 	conf *config.Config
 	log  *slog.Logger
 }
 
-func New(repository Repository, conf *config.Config, log *slog.Logger) *Auth {
+func New(repository Repository, limiter Limiter, conf *config.Config, log *slog.Logger) *Auth {
 	return &Auth{
 		repository: repository,
+		limiter:    limiter,
+		oidc:       &oidcProvider{conf: conf.Auth.Oidc},
 		conf:       conf,
 		log:        log,
 	}
@@ -35,24 +76,28 @@ func (a *Auth) Mount(router chi.Router) {
 
 	group.Get("/sign-in", a.signIn)
 	group.Post("/sign-in", a.submitSignIn)
+	group.Get("/two-factor", a.twoFactor)
+	group.Post("/two-factor", a.submitTwoFactor)
+	group.Get("/sign-up", a.signUp)
+	group.Post("/sign-up", a.submitSignUp)
+	group.Get("/reset-password", a.resetPassword)
+	group.Post("/reset-password", a.submitResetPassword)
+	group.Get("/oidc/login", a.oidcLogin)
+	group.Get("/oidc/callback", a.oidcCallback)
 	group.Get("/sign-out", a.signOut)
+	group.Get("/csrf-error", a.csrfError)
 
 	router.Mount("/auth", group)
 }
 
 func (a *Auth) signIn(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
 	user := GetUser(r)
 	if user != nil {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 		return
 	}
 
-	view := signInView(signInViewData{})
-	err := view.Render(ctx, w)
-	if err != nil {
-		a.log.ErrorContext(ctx, "Failed to render sign in template", "error", err)
-	}
+	a.renderSignIn(w, r, signInViewData{})
 }
 
 func (a *Auth) submitSignIn(w http.ResponseWriter, r *http.Request) {
@@ -60,42 +105,153 @@ func (a *Auth) submitSignIn(w http.ResponseWriter, r *http.Request) {
 
 	username := r.FormValue("username")
 	password := r.FormValue("password")
+	ip := ClientIp(r)
+
+	// Don't even check the password while locked out, hashing it is expensive.
+	if lockedFor := a.limiter.LockedFor(ip, username); lockedFor > 0 {
+		data := signInViewData{
+			Username: username,
+			Error:    fmt.Sprintf("Too many failed attempts, try again in %s", lockedFor.Round(time.Second)),
+		}
+
+		w.WriteHeader(http.StatusTooManyRequests)
+		a.renderSignIn(w, r, data)
+		return
+	}
 
 	user, err := a.Authenticate(ctx, username, password)
 	if err == models.ErrInvalidCredentials {
+		a.limiter.Fail(ctx, ip, username)
+
 		data := signInViewData{
 			Username: username,
 			Password: password,
 			Error:    "Invalid credentials",
 		}
 
-		view := signInView(data)
-		err := view.Render(ctx, w)
+		a.renderSignIn(w, r, data)
+		return
+	} else if err != nil {
+		a.log.ErrorContext(ctx, "Failed to authenticate user", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if user.HasTwoFactor() {
+		session, err := a.CreatePendingSession(&user.User, user.SessionVersion)
 		if err != nil {
-			a.log.ErrorContext(ctx, "Failed to render template", "error", err)
+			a.log.ErrorContext(ctx, "Failed to create pending session", "error", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
 		}
+
+		a.setSessionCookie(w, models.PendingSessionCookieName, session)
+		http.Redirect(w, r, "/auth/two-factor", http.StatusSeeOther)
 		return
-	} else if err != nil {
-		a.log.ErrorContext(ctx, "Failed to authenticate user", "error", err)
+	}
+
+	a.limiter.Succeed(username)
+	a.startSession(w, r, &user.User, user.SessionVersion)
+}
+
+func (a *Auth) twoFactor(w http.ResponseWriter, r *http.Request) {
+	if a.pendingSession(r) == nil {
+		http.Redirect(w, r, "/auth/sign-in", http.StatusSeeOther)
+		return
+	}
+
+	a.renderTwoFactor(w, r, twoFactorViewData{})
+}
+
+func (a *Auth) submitTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	session := a.pendingSession(r)
+	if session == nil {
+		http.Redirect(w, r, "/auth/sign-in", http.StatusSeeOther)
+		return
+	}
+
+	username := session.User.Username
+	ip := ClientIp(r)
+
+	if lockedFor := a.limiter.LockedFor(ip, username); lockedFor > 0 {
+		data := twoFactorViewData{
+			Error: fmt.Sprintf("Too many failed attempts, try again in %s", lockedFor.Round(time.Second)),
+		}
+
+		w.WriteHeader(http.StatusTooManyRequests)
+		a.renderTwoFactor(w, r, data)
+		return
+	}
+
+	ok, err := a.VerifySecondFactor(ctx, session.User.Id, r.FormValue("code"))
+	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	session, err := a.CreateSession(user)
+	if !ok {
+		a.limiter.Fail(ctx, ip, username)
+		a.renderTwoFactor(w, r, twoFactorViewData{Error: "Invalid code"})
+		return
+	}
+
+	a.limiter.Succeed(username)
+	a.clearSessionCookie(w, models.PendingSessionCookieName)
+	a.startSession(w, r, session.User, session.Version)
+}
+
+// pendingSession returns the session of a user that still has to enter the second factor.
+func (a *Auth) pendingSession(r *http.Request) *models.Session {
+	cookie, err := r.Cookie(models.PendingSessionCookieName)
+	if err != nil {
+		return nil
+	}
+
+	session, err := models.SessionFromCookie(cookie.Value)
+	if err != nil {
+		return nil
+	}
+
+	if err := a.ValidatePendingSession(session); err != nil {
+		return nil
+	}
+
+	return session
+}
+
+func (a *Auth) startSession(w http.ResponseWriter, r *http.Request, user *models.User, sessionVersion int) {
+	session, err := a.CreateSession(user, sessionVersion)
 	if err != nil {
-		a.log.ErrorContext(ctx, "Failed to create session", "error", err)
+		a.log.ErrorContext(r.Context(), "Failed to create session", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	ok := a.setSessionCookie(w, models.SessionCookieName, session)
+	if !ok {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
 
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// SetSessionCookie replaces the session of the current user.
+func (a *Auth) SetSessionCookie(w http.ResponseWriter, session *models.Session) bool {
+	return a.setSessionCookie(w, models.SessionCookieName, session)
+}
+
+func (a *Auth) setSessionCookie(w http.ResponseWriter, name string, session *models.Session) bool {
 	sessionCookie, err := session.ToCookie()
 	if err != nil {
 		a.log.Error("Failed to serialize session", "error", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return false
 	}
 
 	cookie := &http.Cookie{
-		Name:     models.SessionCookieName,
+		Name:     name,
 		Value:    sessionCookie,
 		Path:     "/",
 		Expires:  session.ExpiresAt,
@@ -105,12 +261,12 @@ func (a *Auth) submitSignIn(w http.ResponseWriter, r *http.Request) {
 	}
 	http.SetCookie(w, cookie)
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	return true
 }
 
-func (a *Auth) signOut(w http.ResponseWriter, r *http.Request) {
+func (a *Auth) clearSessionCookie(w http.ResponseWriter, name string) {
 	cookie := &http.Cookie{
-		Name:     models.SessionCookieName,
+		Name:     name,
 		Value:    "",
 		Path:     "/",
 		MaxAge:   -1,
@@ -119,6 +275,151 @@ func (a *Auth) signOut(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteLaxMode,
 	}
 	http.SetCookie(w, cookie)
+}
 
+func (a *Auth) renderTwoFactor(w http.ResponseWriter, r *http.Request, data twoFactorViewData) {
+	view := twoFactorView(data)
+	err := view.Render(r.Context(), w)
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "Failed to render template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (a *Auth) renderSignIn(w http.ResponseWriter, r *http.Request, data signInViewData) {
+	if a.conf.Auth.Oidc.Enabled {
+		data.OidcName = a.conf.Auth.Oidc.Name
+	}
+
+	view := signInView(data)
+	err := view.Render(r.Context(), w)
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "Failed to render template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (a *Auth) signUp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := r.URL.Query().Get("token")
+
+	valid, err := a.IsInviteValid(ctx, token)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := signUpViewData{Token: token}
+	if !valid {
+		data.Error = "The invite is invalid, expired or was already used"
+		data.Invalid = true
+	}
+
+	a.renderSignUp(w, r, data)
+}
+
+func (a *Auth) submitSignUp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	data := signUpViewData{
+		Token:    r.FormValue("token"),
+		Username: r.FormValue("username"),
+	}
+
+	password := r.FormValue("password")
+	if password != r.FormValue("confirm_password") {
+		data.Error = "Passwords don't match"
+		a.renderSignUp(w, r, data)
+		return
+	}
+
+	user, err := a.SignUp(ctx, data.Token, data.Username, password)
+
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		data.Error = invalidForm.Message
+		a.renderSignUp(w, r, data)
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	a.log.InfoContext(ctx, "Signed up with invite", "user_id", user.Id)
+	a.startSession(w, r, &user.User, user.SessionVersion)
+}
+
+func (a *Auth) renderSignUp(w http.ResponseWriter, r *http.Request, data signUpViewData) {
+	view := signUpView(data)
+	err := view.Render(r.Context(), w)
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "Failed to render template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (a *Auth) resetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := r.URL.Query().Get("token")
+
+	valid, err := a.IsPasswordResetValid(ctx, token)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := resetPasswordViewData{Token: token}
+	if !valid {
+		data.Error = "The reset link is invalid or has expired"
+		data.Invalid = true
+	}
+
+	a.renderResetPassword(w, r, data)
+}
+
+func (a *Auth) submitResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+
+	if password != r.FormValue("confirm_password") {
+		a.renderResetPassword(w, r, resetPasswordViewData{Token: token, Error: "Passwords don't match"})
+		return
+	}
+
+	err := a.ResetPassword(ctx, token, password)
+
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		a.renderResetPassword(w, r, resetPasswordViewData{Token: token, Error: invalidForm.Message})
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	a.log.InfoContext(ctx, "Password reset")
+	a.clearSessionCookie(w, models.SessionCookieName)
 	http.Redirect(w, r, "/auth/sign-in", http.StatusSeeOther)
 }
+
+func (a *Auth) renderResetPassword(w http.ResponseWriter, r *http.Request, data resetPasswordViewData) {
+	view := resetPasswordView(data)
+	err := view.Render(r.Context(), w)
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "Failed to render template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (a *Auth) signOut(w http.ResponseWriter, r *http.Request) {
+	a.clearSessionCookie(w, models.SessionCookieName)
+	http.Redirect(w, r, "/auth/sign-in", http.StatusSeeOther)
+}
+
+func (a *Auth) csrfError(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusForbidden)
+	err := csrfErrorView().Render(r.Context(), w)
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "Failed to render csrf error template", "error", err)
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/viddrobnic/sparovec/config"
+	"github.com/viddrobnic/sparovec/features/csrf"
+	"github.com/viddrobnic/sparovec/features/htmx"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const csrfSeedCookieName = "csrf_seed"
+
+// CreateCsrfMiddleware protects state changing requests against cross site request forgery.
+// Tokens are derived from the session, so they are valid for as long as the session is.
+// Requests without a session (signing in) use a random seed stored in a cookie instead.
+// Tokens signed with retired keys are accepted, so that pages opened before the key was
+// rotated still work. Must be used after the auth middleware.
+func CreateCsrfMiddleware(conf config.Auth, log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seed, err := csrfSeed(w, r)
+			if err != nil {
+				log.ErrorContext(r.Context(), "Failed to create csrf seed", "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			token := csrfToken(conf.SigningKey, seed)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				requestToken := r.Header.Get(csrf.HeaderName)
+				if requestToken == "" {
+					requestToken = r.FormValue(csrf.FormField)
+				}
+
+				if !isCsrfTokenValid(conf, seed, requestToken) {
+					log.WarnContext(r.Context(), "Invalid csrf token", "path", r.URL.Path, "method", r.Method)
+					rejectCsrf(w, r)
+					return
+				}
+			}
+
+			ctx := csrf.WithToken(r.Context(), token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func csrfSeed(w http.ResponseWriter, r *http.Request) (string, error) {
+	if GetUser(r) != nil {
+		cookie, err := r.Cookie(models.SessionCookieName)
+		if err == nil {
+			session, err := models.SessionFromCookie(cookie.Value)
+			if err == nil {
+				return session.Signature, nil
+			}
+		}
+	}
+
+	cookie, err := r.Cookie(csrfSeedCookieName)
+	if err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+
+	seedBytes := make([]byte, 32)
+	_, err = rand.Read(seedBytes)
+	if err != nil {
+		return "", err
+	}
+
+	seed := base64.RawURLEncoding.EncodeToString(seedBytes)
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfSeedCookieName,
+		Value:    seed,
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return seed, nil
+}
+
+func csrfToken(signingKey, seed string) string {
+	sum := hmac.New(sha256.New, []byte(signingKey))
+	_, _ = sum.Write([]byte("csrf:" + seed))
+	return base64.RawURLEncoding.EncodeToString(sum.Sum(nil))
+}
+
+func isCsrfTokenValid(conf config.Auth, seed, requestToken string) bool {
+	for _, key := range conf.AcceptedSigningKeys(time.Now()) {
+		if hmac.Equal([]byte(csrfToken(key, seed)), []byte(requestToken)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func rejectCsrf(w http.ResponseWriter, r *http.Request) {
+	// Htmx doesn't swap error responses, so redirect to the error page instead.
+	if r.Header.Get(htmx.HeaderRequest) != "" {
+		w.Header().Set(htmx.HeaderRedirect, "/auth/csrf-error")
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	w.WriteHeader(http.StatusForbidden)
+	_ = csrfErrorView().Render(r.Context(), w)
+}
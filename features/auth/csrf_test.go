@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viddrobnic/sparovec/config"
+	"github.com/viddrobnic/sparovec/features/csrf"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+func TestCsrfMiddleware(t *testing.T) {
+	const seed = "seed"
+
+	conf := config.Auth{
+		SigningKey: "current-key",
+		RetiredSigningKeys: []config.SigningKey{
+			{Id: "retired", Key: "retired-key", AcceptUntil: time.Now().Add(time.Hour)},
+			{Id: "expired", Key: "expired-key", AcceptUntil: time.Now().Add(-time.Hour)},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		// Token sent in the header, or in the form if inForm is set.
+		token      string
+		inForm     bool
+		wantStatus int
+	}{
+		{"safe method without token", http.MethodGet, "", false, http.StatusOK},
+		{"missing token", http.MethodPost, "", false, http.StatusForbidden},
+		{"current key in header", http.MethodPost, csrfToken("current-key", seed), false, http.StatusOK},
+		{"current key in form", http.MethodPost, csrfToken("current-key", seed), true, http.StatusOK},
+		{"retired key", http.MethodPost, csrfToken("retired-key", seed), false, http.StatusOK},
+		{"expired key", http.MethodPost, csrfToken("expired-key", seed), false, http.StatusForbidden},
+		{"unknown key", http.MethodPost, csrfToken("unknown-key", seed), false, http.StatusForbidden},
+		{"other seed", http.MethodPost, csrfToken("current-key", "other-seed"), false, http.StatusForbidden},
+		{"invalid token", http.MethodDelete, "invalid", false, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body io.Reader
+			if test.inForm {
+				body = strings.NewReader(url.Values{csrf.FormField: {test.token}}.Encode())
+			}
+
+			request := httptest.NewRequest(test.method, "/", body)
+			request.AddCookie(&http.Cookie{Name: csrfSeedCookieName, Value: seed})
+			if test.inForm {
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else if test.token != "" {
+				request.Header.Set(csrf.HeaderName, test.token)
+			}
+
+			var contextToken string
+			handler := CreateCsrfMiddleware(conf, slog.New(slog.NewTextHandler(io.Discard, nil)))(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					contextToken = csrf.Token(r.Context())
+				}),
+			)
+
+			response := httptest.NewRecorder()
+			handler.ServeHTTP(response, request)
+
+			if response.Code != test.wantStatus {
+				t.Fatalf("status = %d, want %d", response.Code, test.wantStatus)
+			}
+
+			// New pages always get a token signed with the current key.
+			if test.wantStatus == http.StatusOK && contextToken != csrfToken("current-key", seed) {
+				t.Errorf("context token = %q, want token signed with the current key", contextToken)
+			}
+		})
+	}
+}
+
+func TestCsrfSeed(t *testing.T) {
+	session := &models.Session{User: &models.User{Id: 1}, Signature: "signature"}
+	sessionCookie, err := session.ToCookie()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("signed in", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.AddCookie(&http.Cookie{Name: models.SessionCookieName, Value: sessionCookie})
+		request = request.WithContext(models.ContextWithUser(request.Context(), session.User))
+
+		seed, err := csrfSeed(httptest.NewRecorder(), request)
+		if err != nil || seed != "signature" {
+			t.Fatalf("csrfSeed() = %q, %v, want the session signature", seed, err)
+		}
+	})
+
+	t.Run("signed out", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		seed, err := csrfSeed(response, httptest.NewRequest(http.MethodGet, "/", nil))
+		if err != nil || seed == "" {
+			t.Fatalf("csrfSeed() = %q, %v, want a random seed", seed, err)
+		}
+
+		cookies := response.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != csrfSeedCookieName || cookies[0].Value != seed {
+			t.Fatalf("cookies = %v, want the seed cookie", cookies)
+		}
+	})
+}
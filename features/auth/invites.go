@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const (
+	// InviteTtl is how long an invite link is valid for.
+	InviteTtl         = 7 * 24 * time.Hour
+	inviteTokenLength = 32
+)
+
+// CreateInvite creates a single-use invite with which a new user can sign up.
+// createdBy is nil for invites created with the cli. If walletId is set,
+// the new user becomes an editor of the wallet. Callers must check that
+// the inviting user owns the wallet.
+func (a *Auth) CreateInvite(ctx context.Context, createdBy, walletId *int) (string, error) {
+	tokenBytes := make([]byte, inviteTokenLength)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to generate invite token", "error", err)
+		return "", models.ErrInternalServer
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	err = a.repository.InsertInvite(ctx, hashToken(token), createdBy, walletId, InviteTtl)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to insert invite", "error", err)
+		return "", models.ErrInternalServer
+	}
+
+	return token, nil
+}
+
+// InviteUrl returns the sign up link for the invite. If the public url
+// is not configured, the link points to the host of the request.
+func (a *Auth) InviteUrl(r *http.Request, token string) string {
+	return baseUrl(a.conf, r) + "/auth/sign-up?token=" + url.QueryEscape(token)
+}
+
+func (a *Auth) IsInviteValid(ctx context.Context, token string) (bool, error) {
+	valid, err := a.repository.IsInviteValid(ctx, hashToken(token))
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get invite", "error", err)
+		return false, models.ErrInternalServer
+	}
+
+	return valid, nil
+}
+
+// SignUp creates a new user with the invite.
+func (a *Auth) SignUp(ctx context.Context, token, username, password string) (*models.UserCredentials, error) {
+	err := a.validateUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validatePassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, salt, err := newPasswordHash(password)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to generate salt", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	user, err := a.repository.SignUp(ctx, hashToken(token), username, hashedPassword, salt)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to sign up", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if user == nil {
+		return nil, &models.ErrInvalidForm{Message: "The invite is invalid, expired or was already used"}
+	}
+
+	return user, nil
+}
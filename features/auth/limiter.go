@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/viddrobnic/sparovec/config"
+)
+
+const pruneInterval = time.Minute
+
+type loginAttempts struct {
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// LoginLimiter limits failed sign in attempts per ip and per username.
+// After the free attempts are used up, every further failure locks out
+// the ip or username for exponentially longer.
+type LoginLimiter struct {
+	mu        sync.Mutex
+	attempts  map[string]*loginAttempts
+	lastPrune time.Time
+
+	conf config.RateLimit
+	log  *slog.Logger
+}
+
+func NewLoginLimiter(conf *config.Config, log *slog.Logger) *LoginLimiter {
+	limiter := &LoginLimiter{
+		attempts:  make(map[string]*loginAttempts),
+		lastPrune: time.Now(),
+		conf:      conf.Auth.RateLimit,
+		log:       log,
+	}
+
+	if limiter.conf.PersistPath != "" {
+		err := limiter.load()
+		if err != nil {
+			log.Error("Failed to load login limiter state", "error", err)
+		}
+	}
+
+	return limiter
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func userKey(username string) string {
+	return "user:" + username
+}
+
+// LockedFor returns how long sign in is still locked for the ip or the username.
+func (l *LoginLimiter) LockedFor(ip, username string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	lockedFor := time.Duration(0)
+	for _, key := range []string{ipKey(ip), userKey(username)} {
+		attempts, ok := l.attempts[key]
+		if !ok {
+			continue
+		}
+
+		if d := attempts.LockedUntil.Sub(now); d > lockedFor {
+			lockedFor = d
+		}
+	}
+
+	return lockedFor
+}
+
+// Fail records a failed sign in attempt.
+func (l *LoginLimiter) Fail(ctx context.Context, ip, username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	l.fail(ctx, now, ipKey(ip), l.conf.IpFreeAttempts)
+	l.fail(ctx, now, userKey(username), l.conf.UserFreeAttempts)
+
+	l.persist()
+}
+
+func (l *LoginLimiter) fail(ctx context.Context, now time.Time, key string, freeAttempts int) {
+	attempts, ok := l.attempts[key]
+	if !ok {
+		attempts = &loginAttempts{}
+		l.attempts[key] = attempts
+	}
+
+	resetAfter := time.Duration(l.conf.ResetAfter) * time.Second
+	if now.After(attempts.LockedUntil) && now.Sub(attempts.LastFailure) > resetAfter {
+		attempts.Failures = 0
+	}
+
+	attempts.Failures++
+	attempts.LastFailure = now
+
+	if attempts.Failures < freeAttempts {
+		return
+	}
+
+	lockout := time.Duration(l.conf.BaseLockout) * time.Second
+	maxLockout := time.Duration(l.conf.MaxLockout) * time.Second
+	for i := freeAttempts; i < attempts.Failures && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, maxLockout)
+
+	attempts.LockedUntil = now.Add(lockout)
+	l.log.WarnContext(
+		ctx,
+		"Sign in locked out",
+		"key", key,
+		"failures", attempts.Failures,
+		"locked_until", attempts.LockedUntil,
+	)
+}
+
+// Succeed forgets failed attempts for the username after a successful sign in.
+// Failures of the ip are kept until they expire, otherwise signing in to an own
+// account between guesses would reset the limit for guessing other usernames.
+func (l *LoginLimiter) Succeed(username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, ok := l.attempts[userKey(username)]
+	if !ok {
+		return
+	}
+
+	delete(l.attempts, userKey(username))
+	l.persist()
+}
+
+// prune removes attempts that are no longer locked and are older than reset_after,
+// so that guessing random usernames doesn't grow the state forever.
+func (l *LoginLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+
+	resetAfter := time.Duration(l.conf.ResetAfter) * time.Second
+	for key, attempts := range l.attempts {
+		if now.After(attempts.LockedUntil) && now.Sub(attempts.LastFailure) > resetAfter {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+func (l *LoginLimiter) load() error {
+	data, err := os.ReadFile(l.conf.PersistPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &l.attempts)
+}
+
+// persist writes the state to a temporary file first,
+// so that a crash can't leave a partially written file behind.
+func (l *LoginLimiter) persist() {
+	if l.conf.PersistPath == "" {
+		return
+	}
+
+	data, err := json.Marshal(l.attempts)
+	if err != nil {
+		l.log.Error("Failed to marshal login limiter state", "error", err)
+		return
+	}
+
+	tmpPath := l.conf.PersistPath + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0o600)
+	if err != nil {
+		l.log.Error("Failed to write login limiter state", "error", err)
+		return
+	}
+
+	err = os.Rename(tmpPath, l.conf.PersistPath)
+	if err != nil {
+		l.log.Error("Failed to write login limiter state", "error", err)
+	}
+}
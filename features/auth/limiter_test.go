@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/viddrobnic/sparovec/config"
+)
+
+func newTestLimiter(persistPath string) *LoginLimiter {
+	conf := &config.Config{}
+	conf.Auth.RateLimit = config.RateLimit{
+		UserFreeAttempts: 3,
+		IpFreeAttempts:   5,
+		BaseLockout:      30,
+		MaxLockout:       100,
+		ResetAfter:       3600,
+		PersistPath:      persistPath,
+	}
+
+	return NewLoginLimiter(conf, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// attempt is a sign in attempt, which fails unless succeed is set.
+type attempt struct {
+	ip       string
+	username string
+	succeed  bool
+}
+
+func TestLoginLimiter(t *testing.T) {
+	failures := func(n int, ip, username string) []attempt {
+		attempts := make([]attempt, n)
+		for i := range attempts {
+			attempts[i] = attempt{ip: ip, username: username}
+		}
+		return attempts
+	}
+
+	tests := []struct {
+		name     string
+		attempts []attempt
+		ip       string
+		username string
+		// Zero if not locked, otherwise roughly how long.
+		lockedFor time.Duration
+	}{
+		{
+			name:     "free user attempts",
+			attempts: failures(2, "1.1.1.1", "alice"),
+			ip:       "1.1.1.1",
+			username: "alice",
+		},
+		{
+			name:      "user locked after free attempts",
+			attempts:  failures(3, "1.1.1.1", "alice"),
+			ip:        "2.2.2.2",
+			username:  "alice",
+			lockedFor: 30 * time.Second,
+		},
+		{
+			name:      "user lockout doubles",
+			attempts:  failures(4, "1.1.1.1", "alice"),
+			ip:        "1.1.1.1",
+			username:  "alice",
+			lockedFor: 60 * time.Second,
+		},
+		{
+			name:      "user lockout is capped",
+			attempts:  failures(10, "1.1.1.1", "alice"),
+			ip:        "1.1.1.1",
+			username:  "alice",
+			lockedFor: 100 * time.Second,
+		},
+		{
+			name: "ip locked after guessing usernames",
+			attempts: append(
+				failures(2, "1.1.1.1", "alice"),
+				append(failures(2, "1.1.1.1", "bob"), failures(1, "1.1.1.1", "carol")...)...,
+			),
+			ip:        "1.1.1.1",
+			username:  "dave",
+			lockedFor: 30 * time.Second,
+		},
+		{
+			name:     "other ips aren't locked",
+			attempts: failures(5, "1.1.1.1", "alice"),
+			ip:       "2.2.2.2",
+			username: "bob",
+		},
+		{
+			name:     "success forgets user failures",
+			attempts: append(failures(2, "1.1.1.1", "alice"), attempt{ip: "1.1.1.1", username: "alice", succeed: true}),
+			ip:       "2.2.2.2",
+			username: "alice",
+		},
+		{
+			name: "success keeps ip failures",
+			attempts: []attempt{
+				{ip: "1.1.1.1", username: "alice"},
+				{ip: "1.1.1.1", username: "bob"},
+				{ip: "1.1.1.1", username: "mallory", succeed: true},
+				{ip: "1.1.1.1", username: "carol"},
+				{ip: "1.1.1.1", username: "dave"},
+				{ip: "1.1.1.1", username: "mallory", succeed: true},
+				{ip: "1.1.1.1", username: "erin"},
+			},
+			ip:        "1.1.1.1",
+			username:  "mallory",
+			lockedFor: 30 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			limiter := newTestLimiter("")
+
+			for _, attempt := range test.attempts {
+				if attempt.succeed {
+					limiter.Succeed(attempt.username)
+				} else {
+					limiter.Fail(ctx, attempt.ip, attempt.username)
+				}
+			}
+
+			lockedFor := limiter.LockedFor(test.ip, test.username)
+			if test.lockedFor == 0 && lockedFor != 0 {
+				t.Fatalf("locked for %s, want not locked", lockedFor)
+			}
+			if test.lockedFor != 0 && (lockedFor <= test.lockedFor-time.Second || lockedFor > test.lockedFor) {
+				t.Fatalf("locked for %s, want %s", lockedFor, test.lockedFor)
+			}
+		})
+	}
+}
+
+func TestLoginLimiterResetAfter(t *testing.T) {
+	ctx := context.Background()
+	limiter := newTestLimiter("")
+
+	limiter.Fail(ctx, "1.1.1.1", "alice")
+	limiter.Fail(ctx, "1.1.1.1", "alice")
+
+	// Failures older than reset_after are forgotten.
+	for _, attempts := range limiter.attempts {
+		attempts.LastFailure = attempts.LastFailure.Add(-2 * time.Hour)
+	}
+
+	limiter.Fail(ctx, "1.1.1.1", "alice")
+	if lockedFor := limiter.LockedFor("1.1.1.1", "alice"); lockedFor != 0 {
+		t.Fatalf("locked for %s, want not locked", lockedFor)
+	}
+	if failures := limiter.attempts[userKey("alice")].Failures; failures != 1 {
+		t.Fatalf("failures = %d, want 1", failures)
+	}
+}
+
+func TestLoginLimiterPersist(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "rate_limit.json")
+
+	limiter := newTestLimiter(path)
+	for i := 0; i < 3; i++ {
+		limiter.Fail(ctx, "1.1.1.1", "alice")
+	}
+
+	// Lockouts survive a restart.
+	restarted := newTestLimiter(path)
+	if lockedFor := restarted.LockedFor("2.2.2.2", "alice"); lockedFor == 0 {
+		t.Fatal("not locked after restart")
+	}
+}
+
+func TestClientIp(t *testing.T) {
+	proxy := config.Proxy{TrustedProxies: []string{"10.0.0.1"}}
+	peerMiddleware, err := CreatePeerMiddleware(proxy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		wantIp       string
+	}{
+		{"direct", "1.1.1.1:1234", "", "1.1.1.1"},
+		{"spoofed header", "1.1.1.1:1234", "2.2.2.2", "1.1.1.1"},
+		{"trusted proxy", "10.0.0.1:1234", "2.2.2.2", "2.2.2.2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/auth/sign-in", nil)
+			request.RemoteAddr = test.remoteAddr
+			if test.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", test.forwardedFor)
+			}
+
+			var ip string
+			handler := peerMiddleware(middleware.RealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = ClientIp(r)
+			})))
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			if ip != test.wantIp {
+				t.Errorf("ClientIp() = %s, want %s", ip, test.wantIp)
+			}
+		})
+	}
+}
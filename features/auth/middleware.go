@@ -2,22 +2,28 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
 
+	"github.com/viddrobnic/sparovec/config"
 	"github.com/viddrobnic/sparovec/models"
 )
 
-type contextKey string
-
-const contextKeyUser = contextKey("user")
-
 type Service interface {
-	ValidateSession(session *models.Session) error
+	ValidateSession(ctx context.Context, session *models.Session) (*models.User, error)
 }
 
 func CreateMiddleware(service Service) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Already authenticated by the proxy middleware.
+			if GetUser(r) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			cookie, err := r.Cookie(models.SessionCookieName)
 			if err != nil {
 				next.ServeHTTP(w, r)
@@ -30,15 +36,111 @@ func CreateMiddleware(service Service) func(next http.Handler) http.Handler {
 				return
 			}
 
-			if err := service.ValidateSession(session); err != nil {
+			user, err := service.ValidateSession(r.Context(), session)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := models.ContextWithUser(r.Context(), user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+type ProxyService interface {
+	ProxyUser(ctx context.Context, username string) (*models.User, error)
+}
+
+// CreateProxyMiddleware authenticates users by the username header set by a reverse proxy.
+// The header is only trusted from the configured proxies, so the middleware must be used
+// before the RealIP middleware, which overwrites the remote address. Requests without the
+// header fall through to the session auth middleware.
+func CreateProxyMiddleware(conf config.Proxy, service ProxyService, log *slog.Logger) (func(next http.Handler) http.Handler, error) {
+	trustedNetworks, err := conf.TrustedNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username := r.Header.Get(conf.Header)
+			if username == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !isTrustedProxy(r, trustedNetworks) {
+				// Debug only, anyone can send the header and a warning per request would flood the log.
+				log.DebugContext(r.Context(), "Ignoring proxy auth header from untrusted address", "remote_addr", r.RemoteAddr)
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), contextKeyUser, session.User)
+			user, err := service.ProxyUser(r.Context(), username)
+			if errors.Is(err, models.ErrInternalServer) {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			} else if err != nil {
+				// The proxy authenticated the user, but they can't use Šparovec.
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			ctx := models.ContextWithUser(r.Context(), user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
+	}, nil
+}
+
+func isTrustedProxy(r *http.Request, trustedNetworks []*net.IPNet) bool {
+	return isTrustedIp(remoteIp(r), trustedNetworks)
+}
+
+func isTrustedIp(address string, trustedNetworks []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type contextKey string
+
+const contextKeyPeer = contextKey("peer")
+
+// peer is the other end of the connection of the request.
+type peer struct {
+	ip string
+	// Trusted peers are proxies whose forwarded headers are used.
+	trusted bool
+}
+
+// CreatePeerMiddleware remembers the address of the connection, so that the forwarded
+// headers are only used for the client ip when they come from a trusted proxy. The middleware
+// must be used before the RealIP middleware, which overwrites the remote address.
+func CreatePeerMiddleware(conf config.Proxy) (func(next http.Handler) http.Handler, error) {
+	trustedNetworks, err := conf.TrustedNetworks()
+	if err != nil {
+		return nil, err
 	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := remoteIp(r)
+			p := peer{ip: ip, trusted: isTrustedIp(ip, trustedNetworks)}
+
+			ctx := context.WithValue(r.Context(), contextKeyPeer, p)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, nil
 }
 
 func RequiredMiddleware(next http.Handler) http.Handler {
@@ -53,15 +155,19 @@ func RequiredMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func GetUser(r *http.Request) *models.User {
-	user := r.Context().Value(contextKeyUser)
-	if user == nil {
-		return nil
-	}
+// AdminMiddleware only lets admins through. Must be used after RequiredMiddleware.
+func AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := GetUser(r)
+		if user == nil || !user.IsAdmin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
 
-	if user, ok := user.(*models.User); ok {
-		return user
-	}
+		next.ServeHTTP(w, r)
+	})
+}
 
-	return nil
+func GetUser(r *http.Request) *models.User {
+	return models.UserFromContext(r.Context())
 }
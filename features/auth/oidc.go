@@ -0,0 +1,452 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/viddrobnic/sparovec/config"
+	"github.com/viddrobnic/sparovec/models"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcStateCookieName = "oidc_state"
+	// How long the user has to sign in at the provider.
+	oidcStateTtl = 10 * time.Minute
+)
+
+var errOidcUnavailable = errors.New("oidc provider is unavailable")
+
+// oidcProvider discovers the provider on first use, so that the server
+// starts even if the provider is down. Failed discovery is retried.
+type oidcProvider struct {
+	conf config.Oidc
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func (p *oidcProvider) get(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.conf.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	p.provider = provider
+	return provider, nil
+}
+
+// oidcState is stored in a cookie between the redirect to the provider and the callback.
+type oidcState struct {
+	State     string    `json:"state"`
+	Nonce     string    `json:"nonce"`
+	Verifier  string    `json:"verifier"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (a *Auth) oauth2Config(r *http.Request, provider *oidc.Provider) *oauth2.Config {
+	conf := a.conf.Auth.Oidc
+
+	redirectUrl := conf.RedirectUrl
+	if redirectUrl == "" {
+		redirectUrl = baseUrl(a.conf, r) + "/auth/oidc/callback"
+	}
+
+	scopes := conf.Scopes
+	if !containsString(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+
+	return &oauth2.Config{
+		ClientID:     conf.ClientId,
+		ClientSecret: conf.ClientSecret,
+		RedirectURL:  redirectUrl,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+func (a *Auth) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !a.conf.Auth.Oidc.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	provider, err := a.oidc.get(ctx)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to discover oidc provider", "error", err)
+		a.renderSignIn(w, r, signInViewData{Error: a.oidcErrorMessage(errOidcUnavailable)})
+		return
+	}
+
+	state := &oidcState{
+		Verifier:  oauth2.GenerateVerifier(),
+		ExpiresAt: time.Now().Add(oidcStateTtl),
+	}
+	state.State, err = randomToken()
+	if err == nil {
+		state.Nonce, err = randomToken()
+	}
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to generate oidc state", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	cookie, err := a.encodeOidcState(state)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to encode oidc state", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    cookie,
+		Path:     "/auth/oidc",
+		MaxAge:   int(oidcStateTtl.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		// The callback is a top level navigation from the provider, which lax cookies allow.
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	authUrl := a.oauth2Config(r, provider).AuthCodeURL(
+		state.State,
+		oidc.Nonce(state.Nonce),
+		oauth2.S256ChallengeOption(state.Verifier),
+	)
+	http.Redirect(w, r, authUrl, http.StatusFound)
+}
+
+func (a *Auth) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !a.conf.Auth.Oidc.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	user, err := a.completeOidcSignIn(r)
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    "",
+		Path:     "/auth/oidc",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if err != nil {
+		a.renderSignIn(w, r, signInViewData{Error: a.oidcErrorMessage(err)})
+		return
+	}
+
+	// The provider is responsible for the second factor.
+	a.log.InfoContext(ctx, "Signed in with oidc", "user_id", user.Id)
+	a.startSession(w, r, &user.User, user.SessionVersion)
+}
+
+func (a *Auth) oidcErrorMessage(err error) string {
+	var invalidForm *models.ErrInvalidForm
+	switch {
+	case errors.As(err, &invalidForm):
+		return invalidForm.Message
+	case errors.Is(err, errOidcUnavailable):
+		return fmt.Sprintf("Sign in with %s is unavailable, try again later", a.conf.Auth.Oidc.Name)
+	default:
+		return fmt.Sprintf("Sign in with %s failed", a.conf.Auth.Oidc.Name)
+	}
+}
+
+// completeOidcSignIn checks the response of the provider and returns the signed in user.
+func (a *Auth) completeOidcSignIn(r *http.Request) (*models.UserCredentials, error) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	cookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil {
+		return nil, &models.ErrInvalidForm{Message: "Sign in took too long, try again"}
+	}
+
+	state, err := a.decodeOidcState(cookie.Value)
+	if err != nil || state.ExpiresAt.Before(time.Now()) {
+		return nil, &models.ErrInvalidForm{Message: "Sign in took too long, try again"}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state.State)) != 1 {
+		a.log.WarnContext(ctx, "Oidc state mismatch")
+		return nil, models.ErrInvalidCredentials
+	}
+
+	if query.Has("error") {
+		a.log.InfoContext(ctx, "Oidc provider returned an error", "error", query.Get("error"), "description", query.Get("error_description"))
+		return nil, models.ErrInvalidCredentials
+	}
+
+	provider, err := a.oidc.get(ctx)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to discover oidc provider", "error", err)
+		return nil, errOidcUnavailable
+	}
+
+	oauth2Config := a.oauth2Config(r, provider)
+	token, err := oauth2Config.Exchange(ctx, query.Get("code"), oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		a.log.WarnContext(ctx, "Failed to exchange oidc code", "error", err)
+		return nil, models.ErrInvalidCredentials
+	}
+
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		a.log.WarnContext(ctx, "Oidc token response has no id token")
+		return nil, models.ErrInvalidCredentials
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: oauth2Config.ClientID}).Verify(ctx, rawIdToken)
+	if err != nil {
+		a.log.WarnContext(ctx, "Failed to verify oidc id token", "error", err)
+		return nil, models.ErrInvalidCredentials
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(state.Nonce)) != 1 {
+		a.log.WarnContext(ctx, "Oidc nonce mismatch")
+		return nil, models.ErrInvalidCredentials
+	}
+
+	claims := map[string]any{}
+	err = idToken.Claims(&claims)
+	if err != nil {
+		a.log.WarnContext(ctx, "Failed to parse oidc claims", "error", err)
+		return nil, models.ErrInvalidCredentials
+	}
+
+	// Some providers only include the profile and groups in the user info.
+	if provider.UserInfoEndpoint() != "" {
+		userInfo, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+		if err != nil {
+			a.log.WarnContext(ctx, "Failed to get oidc user info", "error", err)
+			return nil, models.ErrInvalidCredentials
+		}
+
+		if userInfo.Subject != idToken.Subject {
+			a.log.WarnContext(ctx, "Oidc user info subject mismatch")
+			return nil, models.ErrInvalidCredentials
+		}
+
+		userInfoClaims := map[string]any{}
+		err = userInfo.Claims(&userInfoClaims)
+		if err != nil {
+			a.log.WarnContext(ctx, "Failed to parse oidc user info claims", "error", err)
+			return nil, models.ErrInvalidCredentials
+		}
+
+		for key, value := range userInfoClaims {
+			if _, ok := claims[key]; !ok {
+				claims[key] = value
+			}
+		}
+	}
+
+	return a.OidcSignIn(ctx, idToken.Issuer, idToken.Subject, claims)
+}
+
+// OidcSignIn returns the user with the given identity at the provider. Unknown
+// identities are linked to an existing user or provisioned, if configured.
+func (a *Auth) OidcSignIn(ctx context.Context, issuer, subject string, claims map[string]any) (*models.UserCredentials, error) {
+	conf := a.conf.Auth.Oidc
+
+	if len(conf.AllowedGroups) > 0 && !hasAnyGroup(claims[conf.GroupsClaim], conf.AllowedGroups) {
+		a.log.InfoContext(ctx, "Oidc user is not in an allowed group", "subject", subject)
+		return nil, &models.ErrInvalidForm{Message: "You are not in a group that is allowed to use Šparovec"}
+	}
+
+	user, err := a.repository.GetByOidcSubject(ctx, issuer, subject)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user by oidc subject", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	username, _ := claims[conf.UsernameClaim].(string)
+
+	if user == nil && conf.LinkByUsername && username != "" {
+		user, err = a.linkOidcUser(ctx, username, issuer, subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if user == nil && conf.AutoProvision {
+		user, err = a.provisionOidcUser(ctx, username, issuer, subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if user == nil {
+		a.log.InfoContext(ctx, "Unknown oidc user", "subject", subject, "username", username)
+		return nil, &models.ErrInvalidForm{Message: "You don't have an account yet, ask an admin to create one"}
+	}
+
+	if user.IsDisabled() {
+		a.log.InfoContext(ctx, "Disabled user tried to sign in with oidc", "user_id", user.Id)
+		return nil, &models.ErrInvalidForm{Message: "Your account is disabled"}
+	}
+
+	return user, nil
+}
+
+// linkOidcUser links the identity to the user with the same username,
+// unless the user is already linked to another identity.
+func (a *Auth) linkOidcUser(ctx context.Context, username, issuer, subject string) (*models.UserCredentials, error) {
+	user, err := a.repository.GetByUsername(ctx, username)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if user == nil || user.OidcSubject.Valid {
+		return nil, nil
+	}
+
+	err = a.repository.SetOidcSubject(ctx, user.Id, issuer, subject)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to link oidc subject", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	a.log.InfoContext(ctx, "Linked user to oidc identity", "user_id", user.Id, "subject", subject)
+	return user, nil
+}
+
+func (a *Auth) provisionOidcUser(ctx context.Context, username, issuer, subject string) (*models.UserCredentials, error) {
+	if username == "" {
+		a.log.WarnContext(ctx, "Oidc username claim is missing", "claim", a.conf.Auth.Oidc.UsernameClaim)
+		return nil, &models.ErrInvalidForm{Message: "The sign in provider didn't send a username"}
+	}
+
+	err := a.validateUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.repository.InsertOidc(ctx, username, issuer, subject)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to insert oidc user", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	a.log.InfoContext(ctx, "Provisioned oidc user", "user_id", user.Id, "subject", subject)
+	return user, nil
+}
+
+// hasAnyGroup checks the groups claim, which is a list of strings or a single string.
+func hasAnyGroup(claim any, allowed []string) bool {
+	var groups []string
+	switch value := claim.(type) {
+	case string:
+		groups = []string{value}
+	case []any:
+		for _, group := range value {
+			if group, ok := group.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	for _, group := range groups {
+		if containsString(allowed, group) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func randomToken() (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func (a *Auth) encodeOidcState(state *oidcState) (string, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(signOidcState(a.conf.Auth.SigningKey, payload)), nil
+}
+
+func (a *Auth) decodeOidcState(cookie string) (*oidcState, error) {
+	payloadPart, signaturePart, ok := strings.Cut(cookie, ".")
+	if !ok {
+		return nil, errors.New("invalid oidc state")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(payloadPart)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(signaturePart)
+	if err != nil {
+		return nil, err
+	}
+
+	// States signed before the key was rotated are still accepted.
+	valid := false
+	for _, key := range a.conf.Auth.AcceptedSigningKeys(time.Now()) {
+		valid = valid || hmac.Equal(signature, signOidcState(key, payload))
+	}
+	if !valid {
+		return nil, errors.New("invalid oidc state signature")
+	}
+
+	state := &oidcState{}
+	err = json.Unmarshal(payload, state)
+	return state, err
+}
+
+func signOidcState(signingKey string, payload []byte) []byte {
+	// Prefixed, so that the signature can't be mistaken for a session signature.
+	sum := hmac.New(sha256.New, []byte(signingKey))
+	sum.Write([]byte("oidc_state:"))
+	sum.Write(payload)
+	return sum.Sum(nil)
+}
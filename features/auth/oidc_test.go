@@ -0,0 +1,495 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"html"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viddrobnic/sparovec/config"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const (
+	testClientId     = "sparovec"
+	testClientSecret = "secret"
+	testKeyId        = "test-key"
+)
+
+// mockProvider is an OpenID Connect provider with discovery, keys and token endpoints.
+// The authorization endpoint is skipped, tests issue codes with authorize instead.
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockCode
+}
+
+// mockCode is an issued authorization code with the id token claims it is exchanged for.
+type mockCode struct {
+	challenge string
+	nonce     string
+	claims    map[string]any
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &mockProvider{key: key, codes: make(map[string]mockCode)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/keys", p.keys)
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+func (p *mockProvider) issuer() string {
+	return p.server.URL
+}
+
+func (p *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]any{
+		"issuer":                                p.issuer(),
+		"authorization_endpoint":                p.issuer() + "/authorize",
+		"token_endpoint":                        p.issuer() + "/token",
+		"jwks_uri":                              p.issuer() + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *mockProvider) keys(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]any{
+		"keys": []map[string]any{{
+			"kty": "RSA",
+			"kid": testKeyId,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientId, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientId, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+	if clientId != testClientId || clientSecret != testClientSecret {
+		writeJson(w, http.StatusUnauthorized, map[string]any{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	code, ok := p.codes[r.FormValue("code")]
+	delete(p.codes, r.FormValue("code"))
+	p.mu.Unlock()
+
+	if !ok || r.FormValue("grant_type") != "authorization_code" {
+		writeJson(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+		return
+	}
+
+	// PKCE: the verifier has to hash to the challenge sent with the authorization request.
+	verifierHash := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifierHash[:]) != code.challenge {
+		writeJson(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   p.issuer(),
+		"aud":   testClientId,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": code.nonce,
+	}
+	for key, value := range code.claims {
+		claims[key] = value
+	}
+
+	idToken, err := p.sign(claims)
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]any{"error": "server_error"})
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// sign creates a compact RS256 JWT with the claims.
+func (p *mockProvider) sign(claims map[string]any) (string, error) {
+	header, err := json.Marshal(map[string]any{"alg": "RS256", "typ": "JWT", "kid": testKeyId})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// authorize plays the part of the user signing in at the provider. It issues a code for
+// the authorization url and returns the query of the callback.
+func (p *mockProvider) authorize(t *testing.T, authUrl string, test oidcTest) url.Values {
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+
+	if query.Get("client_id") != testClientId || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request: %s", authUrl)
+	}
+
+	code := mockCode{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+		claims:    test.claims,
+	}
+	if test.nonce != "" {
+		code.nonce = test.nonce
+	}
+	if test.challenge != "" {
+		code.challenge = test.challenge
+	}
+
+	codeValue, err := randomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.mu.Lock()
+	p.codes[codeValue] = code
+	p.mu.Unlock()
+
+	state := query.Get("state")
+	if test.state != "" {
+		state = test.state
+	}
+
+	return url.Values{"code": {codeValue}, "state": {state}}
+}
+
+func writeJson(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// oidcRepository keeps users in memory. Methods that the oidc sign in doesn't use panic.
+type oidcRepository struct {
+	Repository
+
+	users []*models.UserCredentials
+}
+
+func (r *oidcRepository) GetByUsername(ctx context.Context, username string) (*models.UserCredentials, error) {
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *oidcRepository) GetByOidcSubject(ctx context.Context, issuer, subject string) (*models.UserCredentials, error) {
+	for _, user := range r.users {
+		if user.OidcIssuer.String == issuer && user.OidcSubject.String == subject && user.OidcSubject.Valid {
+			return user, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *oidcRepository) InsertOidc(ctx context.Context, username, issuer, subject string) (*models.UserCredentials, error) {
+	user := &models.UserCredentials{User: models.User{Id: len(r.users) + 1, Username: username}}
+	r.users = append(r.users, user)
+	return user, r.SetOidcSubject(ctx, user.Id, issuer, subject)
+}
+
+func (r *oidcRepository) SetOidcSubject(ctx context.Context, userId int, issuer, subject string) error {
+	for _, user := range r.users {
+		if user.Id == userId {
+			user.OidcIssuer = sql.NullString{String: issuer, Valid: true}
+			user.OidcSubject = sql.NullString{String: subject, Valid: true}
+		}
+	}
+
+	return nil
+}
+
+type oidcTest struct {
+	name  string
+	conf  func(conf *config.Oidc)
+	users []*models.UserCredentials
+	// Claims of the id token, next to the issuer, audience, expiry and nonce.
+	claims map[string]any
+
+	// Override what the provider sends back, to simulate attacks.
+	state     string
+	nonce     string
+	challenge string
+
+	// Username of the signed in user, empty if sign in fails.
+	wantUser  string
+	wantError string
+	check     func(t *testing.T, issuer string, repository *oidcRepository)
+}
+
+func TestOidcSignIn(t *testing.T) {
+	provider := newMockProvider(t)
+
+	linkedUser := func(id int, username, subject string) *models.UserCredentials {
+		return &models.UserCredentials{
+			User:        models.User{Id: id, Username: username},
+			OidcIssuer:  sql.NullString{String: provider.issuer(), Valid: true},
+			OidcSubject: sql.NullString{String: subject, Valid: true},
+		}
+	}
+
+	tests := []oidcTest{
+		{
+			name:     "linked user",
+			users:    []*models.UserCredentials{linkedUser(1, "alice", "sub-alice")},
+			claims:   map[string]any{"sub": "sub-alice"},
+			wantUser: "alice",
+		},
+		{
+			name:      "state mismatch",
+			users:     []*models.UserCredentials{linkedUser(1, "alice", "sub-alice")},
+			claims:    map[string]any{"sub": "sub-alice"},
+			state:     "forged-state",
+			wantError: "Sign in with Mock failed",
+		},
+		{
+			name:      "nonce mismatch",
+			users:     []*models.UserCredentials{linkedUser(1, "alice", "sub-alice")},
+			claims:    map[string]any{"sub": "sub-alice"},
+			nonce:     "replayed-nonce",
+			wantError: "Sign in with Mock failed",
+		},
+		{
+			name:   "code issued for another verifier",
+			users:  []*models.UserCredentials{linkedUser(1, "alice", "sub-alice")},
+			claims: map[string]any{"sub": "sub-alice"},
+			// Challenge of a stolen code, the verifier in the state cookie doesn't match it.
+			challenge: "47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU",
+			wantError: "Sign in with Mock failed",
+		},
+		{
+			name: "user in allowed group",
+			conf: func(conf *config.Oidc) {
+				conf.GroupsClaim = "groups"
+				conf.AllowedGroups = []string{"family"}
+			},
+			users:    []*models.UserCredentials{linkedUser(1, "alice", "sub-alice")},
+			claims:   map[string]any{"sub": "sub-alice", "groups": []string{"friends", "family"}},
+			wantUser: "alice",
+		},
+		{
+			name: "user not in allowed group",
+			conf: func(conf *config.Oidc) {
+				conf.GroupsClaim = "groups"
+				conf.AllowedGroups = []string{"family"}
+			},
+			users:     []*models.UserCredentials{linkedUser(1, "alice", "sub-alice")},
+			claims:    map[string]any{"sub": "sub-alice", "groups": []string{"friends"}},
+			wantError: "You are not in a group that is allowed to use Šparovec",
+		},
+		{
+			name:      "unknown user",
+			claims:    map[string]any{"sub": "sub-bob", "preferred_username": "bob"},
+			wantError: "You don't have an account yet, ask an admin to create one",
+		},
+		{
+			name:     "auto provisioned user",
+			conf:     func(conf *config.Oidc) { conf.AutoProvision = true },
+			claims:   map[string]any{"sub": "sub-bob", "preferred_username": "bob"},
+			wantUser: "bob",
+			check: func(t *testing.T, issuer string, repository *oidcRepository) {
+				user, _ := repository.GetByOidcSubject(context.Background(), issuer, "sub-bob")
+				if user == nil || user.Username != "bob" {
+					t.Errorf("provisioned user = %v, want bob linked to sub-bob", user)
+				}
+			},
+		},
+		{
+			name:      "auto provisioned username taken",
+			conf:      func(conf *config.Oidc) { conf.AutoProvision = true },
+			users:     []*models.UserCredentials{{User: models.User{Id: 1, Username: "bob"}}},
+			claims:    map[string]any{"sub": "sub-bob", "preferred_username": "bob"},
+			wantError: "Username is already taken",
+		},
+		{
+			name:     "linked by username",
+			conf:     func(conf *config.Oidc) { conf.LinkByUsername = true },
+			users:    []*models.UserCredentials{{User: models.User{Id: 1, Username: "bob"}}},
+			claims:   map[string]any{"sub": "sub-bob", "preferred_username": "bob"},
+			wantUser: "bob",
+			check: func(t *testing.T, issuer string, repository *oidcRepository) {
+				user := repository.users[0]
+				if user.OidcIssuer.String != issuer || user.OidcSubject.String != "sub-bob" {
+					t.Errorf("linked identity = %v %v, want %s sub-bob", user.OidcIssuer, user.OidcSubject, issuer)
+				}
+			},
+		},
+		{
+			name:      "username linked to another identity",
+			conf:      func(conf *config.Oidc) { conf.LinkByUsername = true },
+			users:     []*models.UserCredentials{linkedUser(1, "bob", "sub-other")},
+			claims:    map[string]any{"sub": "sub-bob", "preferred_username": "bob"},
+			wantError: "You don't have an account yet, ask an admin to create one",
+			check: func(t *testing.T, issuer string, repository *oidcRepository) {
+				if subject := repository.users[0].OidcSubject.String; subject != "sub-other" {
+					t.Errorf("subject = %s, want sub-other", subject)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf := &config.Config{}
+			conf.Auth.SessionTtl = 3600
+			conf.Auth.SigningKeyId = "test"
+			conf.Auth.SigningKey = "signing-key"
+			conf.Auth.Oidc = config.Oidc{
+				Enabled:       true,
+				Name:          "Mock",
+				Issuer:        provider.issuer(),
+				ClientId:      testClientId,
+				ClientSecret:  testClientSecret,
+				RedirectUrl:   "http://sparovec.test/auth/oidc/callback",
+				UsernameClaim: "preferred_username",
+			}
+			if test.conf != nil {
+				test.conf(&conf.Auth.Oidc)
+			}
+
+			repository := &oidcRepository{users: test.users}
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			a := New(repository, nil, conf, log)
+
+			login := httptest.NewRecorder()
+			a.oidcLogin(login, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+			if login.Code != http.StatusFound {
+				t.Fatalf("login status = %d, want %d", login.Code, http.StatusFound)
+			}
+
+			callbackQuery := provider.authorize(t, login.Header().Get("Location"), test)
+			request := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+callbackQuery.Encode(), nil)
+			for _, cookie := range login.Result().Cookies() {
+				request.AddCookie(cookie)
+			}
+
+			callback := httptest.NewRecorder()
+			a.oidcCallback(callback, request)
+
+			session := sessionFromResponse(t, callback.Result())
+			if test.wantUser != "" {
+				if callback.Code != http.StatusSeeOther || session == nil {
+					t.Fatalf("callback status = %d, want a session for %s", callback.Code, test.wantUser)
+				}
+				if session.User.Username != test.wantUser {
+					t.Errorf("signed in as %s, want %s", session.User.Username, test.wantUser)
+				}
+			} else {
+				if session != nil {
+					t.Fatalf("signed in as %s, want error", session.User.Username)
+				}
+				if !strings.Contains(callback.Body.String(), html.EscapeString(test.wantError)) {
+					t.Errorf("response doesn't contain error %q", test.wantError)
+				}
+			}
+
+			if test.check != nil {
+				test.check(t, provider.issuer(), repository)
+			}
+		})
+	}
+}
+
+func TestOidcStateRetiredKey(t *testing.T) {
+	conf := &config.Config{}
+	conf.Auth.SigningKey = "old-key"
+	a := New(nil, nil, conf, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	cookie, err := a.encodeOidcState(&oidcState{State: "state"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.Auth.SigningKey = "new-key"
+	conf.Auth.RetiredSigningKeys = []config.SigningKey{
+		{Id: "old", Key: "old-key", AcceptUntil: time.Now().Add(time.Hour)},
+	}
+	state, err := a.decodeOidcState(cookie)
+	if err != nil || state.State != "state" {
+		t.Fatalf("decodeOidcState() = %v, %v, want state signed with retired key", state, err)
+	}
+
+	conf.Auth.RetiredSigningKeys[0].AcceptUntil = time.Now().Add(-time.Hour)
+	_, err = a.decodeOidcState(cookie)
+	if err == nil {
+		t.Fatal("decodeOidcState() accepted state signed with expired key")
+	}
+}
+
+func sessionFromResponse(t *testing.T, response *http.Response) *models.Session {
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == models.SessionCookieName && cookie.Value != "" {
+			session, err := models.SessionFromCookie(cookie.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return session
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const (
+	minPasswordLength = 8
+
+	// PasswordResetTtl is how long a password reset link is valid for.
+	PasswordResetTtl         = 24 * time.Hour
+	passwordResetTokenLength = 32
+)
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return &models.ErrInvalidForm{Message: "Password must be at least 8 characters long"}
+	}
+
+	return nil
+}
+
+// ChangePassword changes the password of a user that knows the current one.
+// All existing sessions of the user are invalidated, so a new session
+// for the current user is returned. Wrong current passwords count as failed
+// sign in attempts from the ip, so that a stolen session can't be used to guess it.
+func (a *Auth) ChangePassword(ctx context.Context, ip string, userId int, currentPassword, newPassword string) (*models.Session, error) {
+	user, err := a.repository.GetById(ctx, userId)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if user == nil {
+		return nil, models.ErrNotFound
+	}
+
+	// Don't even check the password while locked out, hashing it is expensive.
+	if lockedFor := a.limiter.LockedFor(ip, user.Username); lockedFor > 0 {
+		return nil, &models.ErrInvalidForm{
+			Message: fmt.Sprintf("Too many failed attempts, try again in %s", lockedFor.Round(time.Second)),
+		}
+	}
+
+	if !doPasswordsMatch(user.Password, user.Salt, currentPassword) {
+		a.limiter.Fail(ctx, ip, user.Username)
+		return nil, &models.ErrInvalidForm{Message: "Current password is incorrect"}
+	}
+
+	a.limiter.Succeed(user.Username)
+
+	err = validatePassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionVersion, err := a.setPassword(ctx, userId, newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.CreateSession(&user.User, sessionVersion)
+}
+
+// SetPassword sets the password without knowing the current one.
+// All existing sessions of the user are invalidated.
+func (a *Auth) SetPassword(ctx context.Context, username, password string) error {
+	user, err := a.repository.GetByUsername(ctx, username)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return models.ErrInternalServer
+	}
+
+	if user == nil {
+		return models.ErrNotFound
+	}
+
+	err = validatePassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.setPassword(ctx, user.Id, password)
+	return err
+}
+
+func (a *Auth) setPassword(ctx context.Context, userId int, password string) (int, error) {
+	hashedPassword, salt, err := newPasswordHash(password)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to generate salt", "error", err)
+		return 0, models.ErrInternalServer
+	}
+
+	sessionVersion, err := a.repository.UpdatePassword(ctx, userId, hashedPassword, salt)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to update password", "error", err)
+		return 0, models.ErrInternalServer
+	}
+
+	return sessionVersion, nil
+}
+
+// IssuePasswordReset creates a one-time token with which the user can set a new password.
+// Previously issued tokens of the user stop working.
+func (a *Auth) IssuePasswordReset(ctx context.Context, username string) (string, error) {
+	user, err := a.repository.GetByUsername(ctx, username)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return "", models.ErrInternalServer
+	}
+
+	if user == nil {
+		return "", models.ErrNotFound
+	}
+
+	tokenBytes := make([]byte, passwordResetTokenLength)
+	_, err = rand.Read(tokenBytes)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to generate password reset token", "error", err)
+		return "", models.ErrInternalServer
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	err = a.repository.InsertPasswordReset(ctx, user.Id, hashToken(token), PasswordResetTtl)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to insert password reset", "error", err)
+		return "", models.ErrInternalServer
+	}
+
+	return token, nil
+}
+
+// PasswordResetUrl returns the link for the password reset. If the public url
+// is not configured, the link points to the host of the request.
+func (a *Auth) PasswordResetUrl(r *http.Request, token string) string {
+	return baseUrl(a.conf, r) + "/auth/reset-password?token=" + url.QueryEscape(token)
+}
+
+func (a *Auth) IsPasswordResetValid(ctx context.Context, token string) (bool, error) {
+	valid, err := a.repository.IsPasswordResetValid(ctx, hashToken(token))
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get password reset", "error", err)
+		return false, models.ErrInternalServer
+	}
+
+	return valid, nil
+}
+
+// ResetPassword sets a new password with a token issued by IssuePasswordReset.
+func (a *Auth) ResetPassword(ctx context.Context, token, password string) error {
+	err := validatePassword(password)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, salt, err := newPasswordHash(password)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to generate salt", "error", err)
+		return models.ErrInternalServer
+	}
+
+	ok, err := a.repository.ResetPassword(ctx, hashToken(token), hashedPassword, salt)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to reset password", "error", err)
+		return models.ErrInternalServer
+	}
+
+	if !ok {
+		return &models.ErrInvalidForm{Message: "The reset link is invalid or has expired"}
+	}
+
+	return nil
+}
+
+// hashToken hashes a random token for storing it in the database.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
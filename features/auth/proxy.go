@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+// ProxyUser returns the user authenticated by the reverse proxy.
+// Unknown users are created, if configured.
+func (a *Auth) ProxyUser(ctx context.Context, username string) (*models.User, error) {
+	user, err := a.repository.GetByUsername(ctx, username)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if user == nil && a.conf.Auth.Proxy.AutoCreate {
+		user, err = a.createProxyUser(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if user == nil {
+		a.log.InfoContext(ctx, "Unknown proxy user", "username", username)
+		return nil, models.ErrNotFound
+	}
+
+	if user.IsDisabled() {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	return &user.User, nil
+}
+
+func (a *Auth) createProxyUser(ctx context.Context, username string) (*models.UserCredentials, error) {
+	err := a.validateUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.repository.InsertWithoutPassword(ctx, username)
+	if err != nil {
+		// Concurrent requests of a new user race to create it.
+		existing, getErr := a.repository.GetByUsername(ctx, username)
+		if getErr == nil && existing != nil {
+			return existing, nil
+		}
+
+		a.log.ErrorContext(ctx, "Failed to insert proxy user", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	a.log.InfoContext(ctx, "Created proxy user", "user_id", user.Id)
+	return user, nil
+}
@@ -3,9 +3,12 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/features/audit"
 	"github.com/viddrobnic/sparovec/models"
 )
 
@@ -17,10 +20,10 @@ func NewRepository(db *sqlx.DB) *RepositoryImpl {
 	return &RepositoryImpl{db: db}
 }
 
-func (r *RepositoryImpl) Insert(ctx context.Context, username, password, salt string) (*models.UserCredentials, error) {
+func (r *RepositoryImpl) Insert(ctx context.Context, username, password, salt string, isAdmin bool) (*models.UserCredentials, error) {
 	builder := sq.Insert("users").
-		Columns("username", "password", "salt").
-		Values(username, password, salt).
+		Columns("username", "password", "salt", "is_admin").
+		Values(username, password, salt, isAdmin).
 		Suffix("RETURNING *")
 
 	stmt, args, err := builder.ToSql()
@@ -49,3 +52,737 @@ func (r *RepositoryImpl) GetByUsername(ctx context.Context, username string) (*m
 
 	return user, err
 }
+
+func (r *RepositoryImpl) GetById(ctx context.Context, id int) (*models.UserCredentials, error) {
+	builder := sq.Select("*").From("users").Where("id = ?", id)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.UserCredentials{}
+	err = r.db.GetContext(ctx, user, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+func (r *RepositoryImpl) GetByOidcSubject(ctx context.Context, issuer, subject string) (*models.UserCredentials, error) {
+	builder := sq.Select("*").From("users").Where(sq.Eq{
+		"oidc_issuer":  issuer,
+		"oidc_subject": subject,
+	})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.UserCredentials{}
+	err = r.db.GetContext(ctx, user, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+// InsertOidc inserts a user that signs in with OpenID Connect. The user has no password.
+func (r *RepositoryImpl) InsertOidc(ctx context.Context, username, issuer, subject string) (*models.UserCredentials, error) {
+	builder := sq.Insert("users").
+		Columns("username", "password", "salt", "oidc_issuer", "oidc_subject").
+		Values(username, "", "", issuer, subject).
+		Suffix("RETURNING *")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.UserCredentials{}
+	err = r.db.GetContext(ctx, user, stmt, args...)
+	return user, err
+}
+
+// InsertWithoutPassword inserts a user that is authenticated by a reverse proxy.
+func (r *RepositoryImpl) InsertWithoutPassword(ctx context.Context, username string) (*models.UserCredentials, error) {
+	builder := sq.Insert("users").
+		Columns("username", "password", "salt").
+		Values(username, "", "").
+		Suffix("RETURNING *")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.UserCredentials{}
+	err = r.db.GetContext(ctx, user, stmt, args...)
+	return user, err
+}
+
+func (r *RepositoryImpl) SetOidcSubject(ctx context.Context, userId int, issuer, subject string) error {
+	builder := sq.Update("users").
+		Set("oidc_issuer", issuer).
+		Set("oidc_subject", subject).
+		Where("id = ?", userId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+func (r *RepositoryImpl) SetTotpPendingSecret(ctx context.Context, userId int, secret string) error {
+	builder := sq.Update("users").
+		Set("totp_pending_secret", secret).
+		Where("id = ?", userId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+// EnableTwoFactor confirms the pending secret. It returns false if two-factor authentication
+// is already enabled or the pending secret was replaced by another setup in the meantime.
+func (r *RepositoryImpl) EnableTwoFactor(ctx context.Context, userId int, secret string, counter int64, codeHashes []string) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Set secret
+	builder := sq.Update("users").
+		Set("totp_secret", secret).
+		Set("totp_last_counter", counter).
+		Set("totp_pending_secret", nil).
+		Where(sq.Eq{
+			"id":                  userId,
+			"totp_secret":         nil,
+			"totp_pending_secret": secret,
+		})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	// Replace recovery codes
+	err = replaceRecoveryCodes(ctx, tx, userId, codeHashes)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *RepositoryImpl) DisableTwoFactor(ctx context.Context, userId int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := sq.Update("users").
+		Set("totp_secret", nil).
+		Set("totp_last_counter", 0).
+		Set("totp_pending_secret", nil).
+		Where("id = ?", userId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	err = replaceRecoveryCodes(ctx, tx, userId, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userId int, codeHashes []string) error {
+	builder := sq.Delete("recovery_codes").Where("user_id = ?", userId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	insertBuilder := sq.Insert("recovery_codes").Columns("user_id", "code_hash")
+	for _, hash := range codeHashes {
+		insertBuilder = insertBuilder.Values(userId, hash)
+	}
+
+	stmt, args, err = insertBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+// UseTotpCounter marks the time step of a code as used. It returns false
+// if a code for the same or a later time step was already used.
+func (r *RepositoryImpl) UseTotpCounter(ctx context.Context, userId int, counter int64) (bool, error) {
+	builder := sq.Update("users").
+		Set("totp_last_counter", counter).
+		Where("id = ?", userId).
+		Where("totp_last_counter < ?", counter)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+// UseRecoveryCode deletes the recovery code. It returns false if the code doesn't exist.
+func (r *RepositoryImpl) UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	builder := sq.Delete("recovery_codes").
+		Where(sq.Eq{
+			"user_id":   userId,
+			"code_hash": codeHash,
+		})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *RepositoryImpl) CountRecoveryCodes(ctx context.Context, userId int) (int, error) {
+	builder := sq.Select("COUNT(*)").From("recovery_codes").Where("user_id = ?", userId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = r.db.GetContext(ctx, &count, stmt, args...)
+	return count, err
+}
+
+// UpdatePassword sets the password and increments the session version,
+// which invalidates existing sessions. It returns the new session version.
+func (r *RepositoryImpl) UpdatePassword(ctx context.Context, userId int, password, salt string) (int, error) {
+	builder := sq.Update("users").
+		Set("password", password).
+		Set("salt", salt).
+		Set("session_version", sq.Expr("session_version + 1")).
+		Where("id = ?", userId).
+		Suffix("RETURNING session_version")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var sessionVersion int
+	err = r.db.GetContext(ctx, &sessionVersion, stmt, args...)
+	return sessionVersion, err
+}
+
+// InsertPasswordReset replaces the password reset tokens of the user with a new one.
+func (r *RepositoryImpl) InsertPasswordReset(ctx context.Context, userId int, tokenHash string, ttl time.Duration) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := sq.Delete("password_resets").Where("user_id = ?", userId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	insertBuilder := sq.Insert("password_resets").
+		Columns("user_id", "token_hash", "expires_at").
+		Values(userId, tokenHash, sq.Expr("datetime('now', ?)", fmt.Sprintf("+%d seconds", int(ttl.Seconds()))))
+
+	stmt, args, err = insertBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *RepositoryImpl) IsPasswordResetValid(ctx context.Context, tokenHash string) (bool, error) {
+	builder := sq.Select("COUNT(*)").
+		From("password_resets").
+		Where("token_hash = ?", tokenHash).
+		Where("expires_at > datetime('now')")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = r.db.GetContext(ctx, &count, stmt, args...)
+	return count > 0, err
+}
+
+// ResetPassword uses up the password reset token and sets the new password.
+// It returns false if the token doesn't exist or has expired.
+func (r *RepositoryImpl) ResetPassword(ctx context.Context, tokenHash, password, salt string) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := sq.Delete("password_resets").
+		Where("token_hash = ?", tokenHash).
+		Where("expires_at > datetime('now')").
+		Suffix("RETURNING user_id")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var userId int
+	err = tx.GetContext(ctx, &userId, stmt, args...)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	updateBuilder := sq.Update("users").
+		Set("password", password).
+		Set("salt", salt).
+		Set("session_version", sq.Expr("session_version + 1")).
+		Where("id = ?", userId)
+
+	stmt, args, err = updateBuilder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *RepositoryImpl) List(ctx context.Context) ([]*models.UserCredentials, error) {
+	builder := sq.Select("*").From("users").OrderBy("username")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	users := []*models.UserCredentials{}
+	err = r.db.SelectContext(ctx, &users, stmt, args...)
+	return users, err
+}
+
+// UpdateUsername renames the user. Sessions contain the username,
+// so existing sessions are invalidated.
+func (r *RepositoryImpl) UpdateUsername(ctx context.Context, userId int, username string) error {
+	builder := sq.Update("users").
+		Set("username", username).
+		Set("session_version", sq.Expr("session_version + 1")).
+		Where("id = ?", userId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+// Delete deletes the user together with the wallets that have no other members.
+// Wallets that would be left without an owner get new owners.
+// It returns the number of deleted wallets.
+func (r *RepositoryImpl) Delete(ctx context.Context, userId int) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	otherMembers := sq.Select("1").
+		From("wallet_users other").
+		Where("other.wallet_id = wallet_users.wallet_id").
+		Where("other.user_id != ?", userId)
+	otherMembersSql, otherMembersArgs, err := otherMembers.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	soleWallets := sq.Select("wallet_id").
+		From("wallet_users").
+		Where("user_id = ?", userId).
+		Where("NOT EXISTS ("+otherMembersSql+")", otherMembersArgs...)
+	soleWalletsSql, soleWalletsArgs, err := soleWallets.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	builder := sq.Delete("wallets").Where("id IN ("+soleWalletsSql+")", soleWalletsArgs...)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	deletedWallets, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// Wallets where the user is the only owner get new owners, so that
+	// someone can still manage them. Editors are preferred over viewers.
+	newOwners := sq.And{
+		sq.Expr("user_id != ?", userId),
+		sq.Expr(`wallet_id IN (
+			SELECT wallet_id FROM wallet_users
+			WHERE user_id = ? AND role = ?
+		)`, userId, models.RoleOwner),
+		sq.Expr(`NOT EXISTS (
+			SELECT 1 FROM wallet_users other
+			WHERE other.wallet_id = wallet_users.wallet_id AND other.user_id != ? AND other.role = ?
+		)`, userId, models.RoleOwner),
+		sq.Or{
+			sq.Eq{"role": models.RoleEditor},
+			sq.Expr(`NOT EXISTS (
+				SELECT 1 FROM wallet_users other
+				WHERE other.wallet_id = wallet_users.wallet_id AND other.role = ?
+			)`, models.RoleEditor),
+		},
+	}
+
+	promoted, err := memberChanges(ctx, tx, sq.Select("wallet_id", "user_id").From("wallet_users").Where(newOwners))
+	if err != nil {
+		return 0, err
+	}
+
+	updateBuilder := sq.Update("wallet_users").
+		Set("role", models.RoleOwner).
+		Where(newOwners)
+
+	stmt, args, err = updateBuilder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := memberChanges(ctx, tx, sq.Select("wallet_id", "user_id").From("wallet_users").Where("user_id = ?", userId))
+	if err != nil {
+		return 0, err
+	}
+
+	builder = sq.Delete("users").Where("id = ?", userId)
+
+	stmt, args, err = builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, change := range promoted {
+		err = audit.RecordChange(ctx, tx, change.WalletId, models.AuditMember, change.EntityId, models.AuditUpdate, change.Before)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, change := range removed {
+		change.Action = models.AuditDelete
+		err = audit.Record(ctx, tx, change)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int(deletedWallets), tx.Commit()
+}
+
+// memberChanges returns the current fields of the selected members, for recording their changes.
+func memberChanges(ctx context.Context, tx *sqlx.Tx, builder sq.SelectBuilder) ([]audit.Change, error) {
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	members := []struct {
+		WalletId int `db:"wallet_id"`
+		UserId   int `db:"user_id"`
+	}{}
+	err = tx.SelectContext(ctx, &members, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]audit.Change, len(members))
+	for i, member := range members {
+		fields, err := audit.MemberFields(ctx, tx, member.WalletId, member.UserId)
+		if err != nil {
+			return nil, err
+		}
+
+		changes[i] = audit.Change{
+			WalletId: member.WalletId,
+			Entity:   models.AuditMember,
+			EntityId: member.UserId,
+			Before:   fields,
+		}
+	}
+
+	return changes, nil
+}
+
+func (r *RepositoryImpl) InsertInvite(ctx context.Context, tokenHash string, createdBy, walletId *int, ttl time.Duration) error {
+	builder := sq.Insert("invites").
+		Columns("token_hash", "created_by", "wallet_id", "expires_at").
+		Values(tokenHash, createdBy, walletId, sq.Expr("datetime('now', ?)", fmt.Sprintf("+%d seconds", int(ttl.Seconds()))))
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+func (r *RepositoryImpl) IsInviteValid(ctx context.Context, tokenHash string) (bool, error) {
+	builder := sq.Select("COUNT(*)").
+		From("invites").
+		Where("token_hash = ?", tokenHash).
+		Where("used_at IS NULL").
+		Where("expires_at > datetime('now')")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = r.db.GetContext(ctx, &count, stmt, args...)
+	return count > 0, err
+}
+
+// SignUp uses up the invite and creates the user, who becomes a member
+// of the invite's wallet. It returns nil if the invite is used or has expired.
+func (r *RepositoryImpl) SignUp(ctx context.Context, tokenHash, username, password, salt string) (*models.UserCredentials, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Use invite
+	builder := sq.Update("invites").
+		Set("used_at", sq.Expr("datetime('now')")).
+		Where("token_hash = ?", tokenHash).
+		Where("used_at IS NULL").
+		Where("expires_at > datetime('now')").
+		Suffix("RETURNING id, wallet_id")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var invite struct {
+		Id       int
+		WalletId sql.NullInt64 `db:"wallet_id"`
+	}
+	err = tx.GetContext(ctx, &invite, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Insert user
+	insertBuilder := sq.Insert("users").
+		Columns("username", "password", "salt").
+		Values(username, password, salt).
+		Suffix("RETURNING *")
+
+	stmt, args, err = insertBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.UserCredentials{}
+	err = tx.GetContext(ctx, user, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	builder = sq.Update("invites").Set("used_by", user.Id).Where("id = ?", invite.Id)
+
+	stmt, args, err = builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Grant wallet
+	if invite.WalletId.Valid {
+		insertBuilder = sq.Insert("wallet_users").
+			Columns("user_id", "wallet_id", "role").
+			Values(user.Id, invite.WalletId.Int64, models.RoleEditor)
+
+		stmt, args, err = insertBuilder.ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		// The new user joins the wallet.
+		userCtx := models.ContextWithUser(ctx, &user.User)
+		err = audit.RecordChange(userCtx, tx, int(invite.WalletId.Int64), models.AuditMember, user.Id, models.AuditCreate, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return user, tx.Commit()
+}
+
+// SetDisabled disables or enables the user. Disabling increments the
+// session version, so the user is signed out immediately.
+func (r *RepositoryImpl) SetDisabled(ctx context.Context, userId int, disabled bool) error {
+	builder := sq.Update("users").Where("id = ?", userId)
+	if disabled {
+		builder = builder.
+			Set("disabled_at", sq.Expr("datetime('now')")).
+			Set("session_version", sq.Expr("session_version + 1"))
+	} else {
+		builder = builder.Set("disabled_at", nil)
+	}
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+func (r *RepositoryImpl) SetAdmin(ctx context.Context, userId int, isAdmin bool) error {
+	builder := sq.Update("users").Set("is_admin", isAdmin).Where("id = ?", userId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	return err
+}
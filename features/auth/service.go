@@ -2,17 +2,17 @@ package auth
 
 import (
 	"context"
-	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
 	"time"
 
+	"github.com/viddrobnic/sparovec/config"
 	"github.com/viddrobnic/sparovec/models"
 )
 
 const saltLenght = 16
 
-func (a *Auth) Authenticate(ctx context.Context, username, password string) (*models.User, error) {
+func (a *Auth) Authenticate(ctx context.Context, username, password string) (*models.UserCredentials, error) {
 	user, err := a.repository.GetByUsername(ctx, username)
 	if err != nil {
 		a.log.Error("Failed to get user", "error", err)
@@ -28,17 +28,28 @@ func (a *Auth) Authenticate(ctx context.Context, username, password string) (*mo
 		return nil, models.ErrInvalidCredentials
 	}
 
-	return &user.User, nil
+	if user.IsDisabled() {
+		a.log.Info("Disabled user tried to sign in", "username", username)
+		return nil, models.ErrInvalidCredentials
+	}
+
+	return user, nil
 }
 
-func (a *Auth) CreateSession(user *models.User) (*models.Session, error) {
+func (a *Auth) CreateSession(user *models.User, sessionVersion int) (*models.Session, error) {
 	// Create session
 	expiresAt := time.Now().Add(time.Duration(a.conf.Auth.SessionTtl) * time.Second)
 	sess := &models.Session{
 		User:      user,
 		ExpiresAt: expiresAt,
+		KeyId:     a.conf.Auth.SigningKeyId,
+		Version:   sessionVersion,
 	}
 
+	return a.sign(sess)
+}
+
+func (a *Auth) sign(sess *models.Session) (*models.Session, error) {
 	signatureBytes, err := signSession(sess, a.conf.Auth.SigningKey)
 	if err != nil {
 		a.log.Error("Failed to sign session", "error", err)
@@ -50,12 +61,56 @@ func (a *Auth) CreateSession(user *models.User) (*models.Session, error) {
 	return sess, nil
 }
 
-func (a *Auth) ValidateSession(session *models.Session) error {
+// ValidateSession validates the session and returns the current state of its user.
+func (a *Auth) ValidateSession(ctx context.Context, session *models.Session) (*models.User, error) {
+	if session.TwoFactorPending {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	err := a.validateSignature(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.validateUser(ctx, session)
+}
+
+// ValidatePendingSession validates a session of a user that still has to enter the second factor.
+func (a *Auth) ValidatePendingSession(session *models.Session) error {
+	if !session.TwoFactorPending {
+		return models.ErrInvalidCredentials
+	}
+
+	return a.validateSignature(session)
+}
+
+// validateUser checks that the user still exists, is not disabled and that
+// the session was created after the last password change.
+func (a *Auth) validateUser(ctx context.Context, session *models.Session) (*models.User, error) {
+	user, err := a.repository.GetById(ctx, session.User.Id)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if user == nil || user.IsDisabled() || user.SessionVersion != session.Version {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	return &user.User, nil
+}
+
+func (a *Auth) validateSignature(session *models.Session) error {
 	if session.ExpiresAt.Before(time.Now()) {
 		return models.ErrInvalidCredentials
 	}
 
-	signatureBytes, err := signSession(session, a.conf.Auth.SigningKey)
+	signingKey, ok := a.signingKey(session.KeyId)
+	if !ok {
+		return models.ErrInvalidCredentials
+	}
+
+	signatureBytes, err := signSession(session, signingKey)
 	if err != nil {
 		a.log.Error("Failed to sign session", "error", err)
 		return models.ErrInternalServer
@@ -73,23 +128,48 @@ func (a *Auth) ValidateSession(session *models.Session) error {
 	return nil
 }
 
-func (a *Auth) CreateUser(ctx context.Context, username, password string) (*models.User, error) {
-	// Create saltBytes
-	saltBytes := make([]byte, saltLenght)
-	_, err := rand.Read(saltBytes)
+// signingKey returns the key with the given id, if sessions signed with it
+// are still accepted. Sessions created before signing keys had ids
+// don't have a key id and were signed with the default key.
+func (a *Auth) signingKey(keyId string) (string, bool) {
+	if keyId == "" {
+		keyId = config.DefaultSigningKeyId
+	}
+
+	if keyId == a.conf.Auth.SigningKeyId {
+		return a.conf.Auth.SigningKey, true
+	}
+
+	for _, key := range a.conf.Auth.RetiredSigningKeys {
+		if key.Id == keyId && time.Now().Before(key.AcceptUntil) {
+			return key.Key, true
+		}
+	}
+
+	return "", false
+}
+
+// CreateUser creates a user with a password. Admins are created as admins right away,
+// so that a failure can't leave a user without the requested role behind.
+func (a *Auth) CreateUser(ctx context.Context, username, password string, isAdmin bool) (*models.User, error) {
+	err := a.validateUsername(ctx, username)
 	if err != nil {
-		a.log.Error("Failed to generate salt", "error", err)
-		return nil, models.ErrInternalServer
+		return nil, err
 	}
 
-	salt := base64.StdEncoding.EncodeToString(saltBytes)
+	err = validatePassword(password)
+	if err != nil {
+		return nil, err
+	}
 
-	// Hash password
-	hashedPasswordBytes := hashPassword([]byte(password), saltBytes)
-	hashedPassword := base64.StdEncoding.EncodeToString(hashedPasswordBytes)
+	hashedPassword, salt, err := newPasswordHash(password)
+	if err != nil {
+		a.log.Error("Failed to generate salt", "error", err)
+		return nil, models.ErrInternalServer
+	}
 
 	// Insert user
-	user, err := a.repository.Insert(ctx, username, hashedPassword, salt)
+	user, err := a.repository.Insert(ctx, username, hashedPassword, salt, isAdmin)
 	if err != nil {
 		a.log.Error("Failed to insert user", "error", err)
 		return nil, models.ErrInternalServer
@@ -0,0 +1,324 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const (
+	totpIssuer = "Šparovec"
+	totpPeriod = 30
+	// Number of periods before and after the current one in which a code is still accepted,
+	// to allow for clock drift.
+	totpSkew = 1
+
+	nrRecoveryCodes     = 10
+	recoveryCodeLength  = 10
+	pendingSignInTtl    = 5 * time.Minute
+	totpQrCodeImageSize = 200
+)
+
+type TwoFactorSetup struct {
+	Secret string
+	// QR code for authenticator apps as a png data url.
+	QrCode string
+}
+
+var errTwoFactorEnabled = &models.ErrInvalidForm{
+	Message: "Two-factor authentication is already enabled, disable it first",
+}
+
+// NewTwoFactorSetup generates a secret, which is kept as pending until the user confirms
+// it with a code. Users that already have two-factor authentication have to disable it
+// first, so that a stolen session can't replace the second factor.
+func (a *Auth) NewTwoFactorSetup(ctx context.Context, user *models.User) (*TwoFactorSetup, error) {
+	creds, err := a.twoFactorUser(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.HasTwoFactor() {
+		return nil, errTwoFactorEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Username,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to generate totp key", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	err = a.repository.SetTotpPendingSecret(ctx, user.Id, key.Secret())
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to set pending totp secret", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	return a.newTwoFactorSetup(key)
+}
+
+// PendingTwoFactorSetup recreates the setup that wasn't confirmed yet, so the QR code
+// can be shown again. It returns nil if there is no pending setup.
+func (a *Auth) PendingTwoFactorSetup(ctx context.Context, user *models.User) (*TwoFactorSetup, error) {
+	creds, err := a.twoFactorUser(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.HasTwoFactor() || !creds.TotpPendingSecret.Valid {
+		return nil, nil
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Username,
+		Period:      totpPeriod,
+		Secret:      decodeTotpSecret(creds.TotpPendingSecret.String),
+	})
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to generate totp key", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	return a.newTwoFactorSetup(key)
+}
+
+func (a *Auth) twoFactorUser(ctx context.Context, userId int) (*models.UserCredentials, error) {
+	user, err := a.repository.GetById(ctx, userId)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if user == nil {
+		return nil, models.ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (a *Auth) newTwoFactorSetup(key *otp.Key) (*TwoFactorSetup, error) {
+	img, err := key.Image(totpQrCodeImageSize, totpQrCodeImageSize)
+	if err != nil {
+		a.log.Error("Failed to create totp qr code", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		a.log.Error("Failed to encode totp qr code", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	return &TwoFactorSetup{
+		Secret: key.Secret(),
+		QrCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+func decodeTotpSecret(secret string) []byte {
+	secretBytes, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	return secretBytes
+}
+
+// EnableTwoFactor enables two-factor authentication if the code matches the pending secret.
+// It returns the recovery codes, which are only stored hashed and can't be shown again.
+func (a *Auth) EnableTwoFactor(ctx context.Context, userId int, code string) ([]string, error) {
+	user, err := a.twoFactorUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.HasTwoFactor() {
+		return nil, errTwoFactorEnabled
+	}
+
+	if !user.TotpPendingSecret.Valid {
+		return nil, &models.ErrInvalidForm{Message: "Two-factor setup expired, start again"}
+	}
+
+	secret := user.TotpPendingSecret.String
+	counter, ok := matchTotpCode(secret, code, time.Now())
+	if !ok {
+		return nil, &models.ErrInvalidForm{Message: "Invalid code"}
+	}
+
+	codes := make([]string, nrRecoveryCodes)
+	hashes := make([]string, nrRecoveryCodes)
+	for i := range codes {
+		recoveryCode, err := generateRecoveryCode()
+		if err != nil {
+			a.log.ErrorContext(ctx, "Failed to generate recovery code", "error", err)
+			return nil, models.ErrInternalServer
+		}
+
+		codes[i] = recoveryCode
+		hashes[i] = hashRecoveryCode(recoveryCode)
+	}
+
+	// The code used for enabling counts as used.
+	ok, err = a.repository.EnableTwoFactor(ctx, userId, secret, counter, hashes)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to enable two-factor authentication", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	// Another setup replaced the secret, or enabled two-factor authentication in the meantime.
+	if !ok {
+		return nil, &models.ErrInvalidForm{Message: "Two-factor setup expired, start again"}
+	}
+
+	return codes, nil
+}
+
+func (a *Auth) DisableTwoFactor(ctx context.Context, userId int, code string) error {
+	ok, err := a.VerifySecondFactor(ctx, userId, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return &models.ErrInvalidForm{Message: "Invalid code"}
+	}
+
+	err = a.repository.DisableTwoFactor(ctx, userId)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to disable two-factor authentication", "error", err)
+		return models.ErrInternalServer
+	}
+
+	return nil
+}
+
+// ResetTwoFactor disables two-factor authentication without a code,
+// for users that lost access to their authenticator and recovery codes.
+func (a *Auth) ResetTwoFactor(ctx context.Context, username string) error {
+	user, err := a.repository.GetByUsername(ctx, username)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return models.ErrInternalServer
+	}
+
+	if user == nil {
+		return models.ErrNotFound
+	}
+
+	err = a.repository.DisableTwoFactor(ctx, user.Id)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to disable two-factor authentication", "error", err)
+		return models.ErrInternalServer
+	}
+
+	return nil
+}
+
+// VerifySecondFactor checks the code from the authenticator app or one of the recovery codes.
+// Both can only be used once.
+func (a *Auth) VerifySecondFactor(ctx context.Context, userId int, code string) (bool, error) {
+	user, err := a.repository.GetById(ctx, userId)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return false, models.ErrInternalServer
+	}
+
+	if user == nil || !user.HasTwoFactor() {
+		return false, nil
+	}
+
+	if counter, ok := matchTotpCode(user.TotpSecret.String, code, time.Now()); ok {
+		ok, err := a.repository.UseTotpCounter(ctx, userId, counter)
+		if err != nil {
+			a.log.ErrorContext(ctx, "Failed to use totp code", "error", err)
+			return false, models.ErrInternalServer
+		}
+
+		return ok, nil
+	}
+
+	ok, err := a.repository.UseRecoveryCode(ctx, userId, hashRecoveryCode(code))
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to use recovery code", "error", err)
+		return false, models.ErrInternalServer
+	}
+
+	return ok, nil
+}
+
+// CreatePendingSession creates a short lived session for a user that
+// still has to enter the second factor.
+func (a *Auth) CreatePendingSession(user *models.User, sessionVersion int) (*models.Session, error) {
+	sess := &models.Session{
+		User:             user,
+		ExpiresAt:        time.Now().Add(pendingSignInTtl),
+		KeyId:            a.conf.Auth.SigningKeyId,
+		Version:          sessionVersion,
+		TwoFactorPending: true,
+	}
+
+	return a.sign(sess)
+}
+
+// matchTotpCode returns the time step of the code, if it is valid at the given time.
+func matchTotpCode(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != otp.DigitsSix.Length() {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		expected, err := hotp.GenerateCodeCustom(secret, uint64(counter), hotp.ValidateOpts{
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+
+		if expected == code {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+func generateRecoveryCode() (string, error) {
+	codeBytes := make([]byte, recoveryCodeLength)
+	_, err := rand.Read(codeBytes)
+	if err != nil {
+		return "", err
+	}
+
+	code := base32.StdEncoding.EncodeToString(codeBytes)[:recoveryCodeLength]
+	code = strings.ToLower(code)
+	return code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:], nil
+}
+
+// hashRecoveryCode hashes a normalized recovery code. Recovery codes are random,
+// so a fast hash is enough, unlike for passwords.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/viddrobnic/sparovec/config"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+// twoFactorRepository keeps one user in memory. Methods that two-factor
+// authentication doesn't use panic.
+type twoFactorRepository struct {
+	Repository
+
+	user          *models.UserCredentials
+	recoveryCodes map[string]bool
+}
+
+func (r *twoFactorRepository) GetById(ctx context.Context, id int) (*models.UserCredentials, error) {
+	if id != r.user.Id {
+		return nil, nil
+	}
+
+	user := *r.user
+	return &user, nil
+}
+
+func (r *twoFactorRepository) SetTotpPendingSecret(ctx context.Context, userId int, secret string) error {
+	r.user.TotpPendingSecret = sql.NullString{String: secret, Valid: true}
+	return nil
+}
+
+func (r *twoFactorRepository) EnableTwoFactor(ctx context.Context, userId int, secret string, counter int64, codeHashes []string) (bool, error) {
+	if r.user.HasTwoFactor() || r.user.TotpPendingSecret.String != secret {
+		return false, nil
+	}
+
+	r.user.TotpSecret = sql.NullString{String: secret, Valid: true}
+	r.user.TotpLastCounter = counter
+	r.user.TotpPendingSecret = sql.NullString{}
+
+	r.recoveryCodes = make(map[string]bool)
+	for _, hash := range codeHashes {
+		r.recoveryCodes[hash] = true
+	}
+	return true, nil
+}
+
+func (r *twoFactorRepository) DisableTwoFactor(ctx context.Context, userId int) error {
+	r.user.TotpSecret = sql.NullString{}
+	r.user.TotpLastCounter = 0
+	r.user.TotpPendingSecret = sql.NullString{}
+	r.recoveryCodes = nil
+	return nil
+}
+
+func (r *twoFactorRepository) UseTotpCounter(ctx context.Context, userId int, counter int64) (bool, error) {
+	if counter <= r.user.TotpLastCounter {
+		return false, nil
+	}
+
+	r.user.TotpLastCounter = counter
+	return true, nil
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	ok := r.recoveryCodes[codeHash]
+	delete(r.recoveryCodes, codeHash)
+	return ok, nil
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := totp.GenerateCodeCustom(secret, at, totp.ValidateOpts{
+		Period:    totpPeriod,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func newTwoFactorAuth() (*Auth, *twoFactorRepository) {
+	repository := &twoFactorRepository{
+		user: &models.UserCredentials{User: models.User{Id: 1, Username: "alice"}},
+	}
+
+	a := New(repository, nil, &config.Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return a, repository
+}
+
+func formError(err error) string {
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		return invalidForm.Message
+	}
+	return ""
+}
+
+func TestEnableTwoFactor(t *testing.T) {
+	tests := []struct {
+		name string
+		// Returns the code to enable with.
+		prepare   func(t *testing.T, a *Auth, repository *twoFactorRepository) string
+		wantError string
+	}{
+		{
+			name: "valid code",
+			prepare: func(t *testing.T, a *Auth, repository *twoFactorRepository) string {
+				setup, err := a.NewTwoFactorSetup(context.Background(), &repository.user.User)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return totpCode(t, setup.Secret, time.Now())
+			},
+		},
+		{
+			name: "invalid code",
+			prepare: func(t *testing.T, a *Auth, repository *twoFactorRepository) string {
+				_, err := a.NewTwoFactorSetup(context.Background(), &repository.user.User)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return "000000"
+			},
+			wantError: "Invalid code",
+		},
+		{
+			name: "code of an old setup",
+			prepare: func(t *testing.T, a *Auth, repository *twoFactorRepository) string {
+				old, err := a.NewTwoFactorSetup(context.Background(), &repository.user.User)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = a.NewTwoFactorSetup(context.Background(), &repository.user.User)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return totpCode(t, old.Secret, time.Now())
+			},
+			wantError: "Invalid code",
+		},
+		{
+			name: "without setup",
+			prepare: func(t *testing.T, a *Auth, repository *twoFactorRepository) string {
+				return "123456"
+			},
+			wantError: "Two-factor setup expired, start again",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, repository := newTwoFactorAuth()
+			code := test.prepare(t, a, repository)
+
+			codes, err := a.EnableTwoFactor(context.Background(), 1, code)
+			if test.wantError != "" {
+				if formError(err) != test.wantError {
+					t.Fatalf("EnableTwoFactor() error = %v, want %q", err, test.wantError)
+				}
+				if repository.user.HasTwoFactor() {
+					t.Fatal("two-factor authentication was enabled")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("EnableTwoFactor() error = %v", err)
+			}
+			if len(codes) != nrRecoveryCodes || len(repository.recoveryCodes) != nrRecoveryCodes {
+				t.Errorf("got %d recovery codes, %d stored, want %d", len(codes), len(repository.recoveryCodes), nrRecoveryCodes)
+			}
+			if !repository.user.HasTwoFactor() || repository.user.TotpPendingSecret.Valid {
+				t.Error("two-factor authentication not enabled with the pending secret")
+			}
+		})
+	}
+}
+
+func TestTwoFactorAlreadyEnabled(t *testing.T) {
+	ctx := context.Background()
+	a, repository := newTwoFactorAuth()
+	repository.user.TotpSecret = sql.NullString{String: "JBSWY3DPEHPK3PXP", Valid: true}
+
+	_, err := a.NewTwoFactorSetup(ctx, &repository.user.User)
+	if !errors.Is(err, errTwoFactorEnabled) {
+		t.Fatalf("NewTwoFactorSetup() error = %v, want %v", err, errTwoFactorEnabled)
+	}
+
+	// Even with a pending secret left over, the current secret can't be replaced.
+	repository.user.TotpPendingSecret = sql.NullString{String: "KRSXG5CTMVRXEZLU", Valid: true}
+	setup, err := a.PendingTwoFactorSetup(ctx, &repository.user.User)
+	if setup != nil || err != nil {
+		t.Fatalf("PendingTwoFactorSetup() = %v, %v, want no setup", setup, err)
+	}
+
+	_, err = a.EnableTwoFactor(ctx, 1, totpCode(t, "KRSXG5CTMVRXEZLU", time.Now()))
+	if !errors.Is(err, errTwoFactorEnabled) {
+		t.Fatalf("EnableTwoFactor() error = %v, want %v", err, errTwoFactorEnabled)
+	}
+
+	if repository.user.TotpSecret.String != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("secret = %s, want the original one", repository.user.TotpSecret.String)
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	enable := func(t *testing.T) (*Auth, *twoFactorRepository, string, []string) {
+		a, repository := newTwoFactorAuth()
+		setup, err := a.NewTwoFactorSetup(context.Background(), &repository.user.User)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		codes, err := a.EnableTwoFactor(context.Background(), 1, totpCode(t, setup.Secret, time.Now()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a, repository, setup.Secret, codes
+	}
+
+	tests := []struct {
+		name string
+		code func(t *testing.T, secret string, recoveryCodes []string) string
+		ok   bool
+	}{
+		{
+			name: "next code",
+			code: func(t *testing.T, secret string, recoveryCodes []string) string {
+				return totpCode(t, secret, time.Now().Add(totpPeriod*time.Second))
+			},
+			ok: true,
+		},
+		{
+			name: "code used for enabling",
+			code: func(t *testing.T, secret string, recoveryCodes []string) string {
+				return totpCode(t, secret, time.Now())
+			},
+		},
+		{
+			name: "recovery code",
+			code: func(t *testing.T, secret string, recoveryCodes []string) string {
+				return recoveryCodes[0]
+			},
+			ok: true,
+		},
+		{
+			name: "recovery code without dash",
+			code: func(t *testing.T, secret string, recoveryCodes []string) string {
+				return recoveryCodes[1][:5] + recoveryCodes[1][6:]
+			},
+			ok: true,
+		},
+		{
+			name: "wrong code",
+			code: func(t *testing.T, secret string, recoveryCodes []string) string {
+				return "abcde-fghij"
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, repository, secret, recoveryCodes := enable(t)
+
+			err := a.DisableTwoFactor(context.Background(), 1, test.code(t, secret, recoveryCodes))
+			if test.ok {
+				if err != nil {
+					t.Fatalf("DisableTwoFactor() error = %v", err)
+				}
+				if repository.user.HasTwoFactor() {
+					t.Fatal("two-factor authentication still enabled")
+				}
+				return
+			}
+
+			if formError(err) != "Invalid code" {
+				t.Fatalf("DisableTwoFactor() error = %v, want invalid code", err)
+			}
+			if !repository.user.HasTwoFactor() {
+				t.Fatal("two-factor authentication was disabled")
+			}
+		})
+	}
+}
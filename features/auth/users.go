@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+func (a *Auth) ListUsers(ctx context.Context) ([]*models.UserCredentials, error) {
+	users, err := a.repository.List(ctx)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to list users", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	return users, nil
+}
+
+func (a *Auth) GetUser(ctx context.Context, username string) (*models.UserCredentials, error) {
+	user, err := a.repository.GetByUsername(ctx, username)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if user == nil {
+		return nil, models.ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (a *Auth) RenameUser(ctx context.Context, username, newUsername string) error {
+	user, err := a.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	err = a.validateUsername(ctx, newUsername)
+	if err != nil {
+		return err
+	}
+
+	err = a.repository.UpdateUsername(ctx, user.Id, newUsername)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to update username", "error", err)
+		return models.ErrInternalServer
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user and the wallets no one else is a member of.
+// It returns the number of deleted wallets.
+func (a *Auth) DeleteUser(ctx context.Context, username string) (int, error) {
+	user, err := a.GetUser(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+
+	deletedWallets, err := a.repository.Delete(ctx, user.Id)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to delete user", "error", err)
+		return 0, models.ErrInternalServer
+	}
+
+	return deletedWallets, nil
+}
+
+func (a *Auth) SetUserDisabled(ctx context.Context, username string, disabled bool) error {
+	user, err := a.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	err = a.repository.SetDisabled(ctx, user.Id, disabled)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to set user disabled", "error", err)
+		return models.ErrInternalServer
+	}
+
+	return nil
+}
+
+func (a *Auth) SetUserAdmin(ctx context.Context, username string, isAdmin bool) error {
+	user, err := a.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	err = a.repository.SetAdmin(ctx, user.Id, isAdmin)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to set user admin", "error", err)
+		return models.ErrInternalServer
+	}
+
+	return nil
+}
+
+// validateUsername checks that the username is valid and not taken.
+func (a *Auth) validateUsername(ctx context.Context, username string) error {
+	if username == "" || strings.TrimSpace(username) != username {
+		return &models.ErrInvalidForm{Message: "Username must not be empty or start or end with a space"}
+	}
+
+	existing, err := a.repository.GetByUsername(ctx, username)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return models.ErrInternalServer
+	}
+
+	if existing != nil {
+		return &models.ErrInvalidForm{Message: "Username is already taken"}
+	}
+
+	return nil
+}
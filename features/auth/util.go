@@ -2,11 +2,15 @@ package auth
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
 	"encoding/json"
+	"net"
+	"net/http"
 
+	"github.com/viddrobnic/sparovec/config"
 	"github.com/viddrobnic/sparovec/models"
 	"golang.org/x/crypto/argon2"
 )
@@ -15,7 +19,28 @@ func hashPassword(password, salt []byte) []byte {
 	return argon2.IDKey(password, salt, 1, 64*1024, 4, 32)
 }
 
+// newPasswordHash hashes the password with a new random salt.
+// Both are returned base64 encoded, as they are stored in the database.
+func newPasswordHash(password string) (string, string, error) {
+	saltBytes := make([]byte, saltLenght)
+	_, err := rand.Read(saltBytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	hashedPasswordBytes := hashPassword([]byte(password), saltBytes)
+
+	hashedPassword := base64.StdEncoding.EncodeToString(hashedPasswordBytes)
+	salt := base64.StdEncoding.EncodeToString(saltBytes)
+	return hashedPassword, salt, nil
+}
+
 func doPasswordsMatch(hashedPassword, salt, password string) bool {
+	// Users that sign in with oidc don't have a password.
+	if hashedPassword == "" {
+		return false
+	}
+
 	hashedPasswordBytes, err := base64.StdEncoding.DecodeString(hashedPassword)
 	if err != nil {
 		return false
@@ -47,3 +72,37 @@ func signSession(sess *models.Session, signingKey string) ([]byte, error) {
 
 	return sum.Sum(nil), nil
 }
+
+func baseUrl(conf *config.Config, r *http.Request) string {
+	if conf.API.PublicUrl != "" {
+		return conf.API.BaseUrl()
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// ClientIp returns the ip that sign in attempts are limited by. Forwarded headers can be set
+// by anyone, so the ip from the headers is only used on requests from trusted proxies.
+func ClientIp(r *http.Request) string {
+	p, ok := r.Context().Value(contextKeyPeer).(peer)
+	if ok && !p.trusted {
+		return p.ip
+	}
+
+	return remoteIp(r)
+}
+
+func remoteIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// RealIP middleware sets the remote address without the port.
+		return r.RemoteAddr
+	}
+
+	return host
+}
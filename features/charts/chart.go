@@ -0,0 +1,255 @@
+package charts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/a-h/templ"
+)
+
+// Series are the values of one set of bars or one line, one value for each label of the chart.
+type Series struct {
+	Name string
+	// Hex color, like #ef4444.
+	Color  string
+	Values []float64
+	// Draw the series as a line over the bars.
+	Line bool
+}
+
+// Chart is a bar or line chart, or both, with the labels along the x axis.
+// It can be used as a templ component.
+type Chart struct {
+	Labels []string
+	Series []Series
+	// Stack the bars of a label on top of each other instead of side by side.
+	Stacked bool
+	// Appended to the values on the y axis, like " €".
+	Unit string
+	// Formats values in tooltips. By default, values are shown with two decimals and the unit.
+	Format func(value float64) string
+}
+
+func (c Chart) format(value float64) string {
+	if c.Format != nil {
+		return c.Format(value)
+	}
+	return formatTick(value, 0.01) + c.Unit
+}
+
+const (
+	chartWidth  = 800
+	chartHeight = 320
+	marginLeft  = 70
+	marginRight = 10
+	marginTop   = 10
+	// Space for the labels of the x axis.
+	marginBottom = 30
+	// The most labels shown on the x axis, the rest are only in tooltips.
+	maxLabels = 12
+	ticks     = 5
+)
+
+func (c Chart) Render(ctx context.Context, w io.Writer) error {
+	names := make([]string, len(c.Series))
+	colors := make([]string, len(c.Series))
+	for i, series := range c.Series {
+		names[i] = series.Name
+		colors[i] = series.Color
+	}
+
+	plotWidth := float64(chartWidth - marginLeft - marginRight)
+	legendTop := float64(chartHeight) + 10
+	height := legendTop + legendHeight(plotWidth, names)
+	plotHeight := float64(chartHeight - marginTop - marginBottom)
+
+	s := &svg{}
+	s.open(chartWidth, height, "Chart")
+
+	min, max := c.bounds()
+	step := niceStep((max - min) / ticks)
+	min = math.Floor(min/step) * step
+	max = math.Ceil(max/step) * step
+	if max == min {
+		max = min + step
+	}
+
+	y := func(value float64) float64 {
+		return marginTop + (max-value)/(max-min)*plotHeight
+	}
+
+	// Grid with values of the y axis.
+	for i := 0; min+float64(i)*step <= max+step/2; i++ {
+		value := min + float64(i)*step
+		s.printf(
+			`<line x1="%d" x2="%d" y1="%s" y2="%s" stroke="%s" stroke-width="1"></line>`,
+			marginLeft, chartWidth-marginRight, num(y(value)), num(y(value)), gridColor,
+		)
+		s.text(marginLeft-8, y(value)+4, "end", formatTick(value, step)+c.Unit)
+	}
+
+	if len(c.Labels) > 0 {
+		band := plotWidth / float64(len(c.Labels))
+		c.drawBars(s, band, y)
+		c.drawLines(s, band, y)
+
+		every := int(math.Ceil(float64(len(c.Labels)) / maxLabels))
+		for i, label := range c.Labels {
+			if i%every == 0 {
+				s.text(marginLeft+band*(float64(i)+0.5), chartHeight-marginBottom+18, "middle", label)
+			}
+		}
+	}
+
+	// Zero line.
+	s.printf(
+		`<line x1="%d" x2="%d" y1="%s" y2="%s" stroke="%s" stroke-width="1"></line>`,
+		marginLeft, chartWidth-marginRight, num(y(0)), num(y(0)), textColor,
+	)
+
+	s.legend(marginLeft, legendTop+10, plotWidth, names, colors)
+	s.close()
+
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// bounds returns the smallest and largest value drawn, always including zero.
+func (c Chart) bounds() (float64, float64) {
+	min, max := 0.0, 0.0
+	for i := range c.Labels {
+		positive, negative := 0.0, 0.0
+		for _, series := range c.Series {
+			value := series.value(i)
+			if c.Stacked && !series.Line {
+				if value > 0 {
+					positive += value
+				} else {
+					negative += value
+				}
+				value = 0
+			}
+
+			min = math.Min(min, value)
+			max = math.Max(max, value)
+		}
+
+		min = math.Min(min, negative)
+		max = math.Max(max, positive)
+	}
+
+	return min, max
+}
+
+func (s Series) value(i int) float64 {
+	if i < len(s.Values) {
+		return s.Values[i]
+	}
+	return 0
+}
+
+func (c Chart) drawBars(s *svg, band float64, y func(float64) float64) {
+	bars := []Series{}
+	for _, series := range c.Series {
+		if !series.Line {
+			bars = append(bars, series)
+		}
+	}
+	if len(bars) == 0 {
+		return
+	}
+
+	groupWidth := band * 0.7
+	barWidth := groupWidth / float64(len(bars))
+	if c.Stacked {
+		barWidth = groupWidth
+	}
+
+	for i, label := range c.Labels {
+		x := marginLeft + band*float64(i) + (band-groupWidth)/2
+		positive, negative := 0.0, 0.0
+
+		for j, series := range bars {
+			value := series.value(i)
+			from, to := 0.0, value
+			if c.Stacked {
+				if value > 0 {
+					from, to = positive, positive+value
+					positive = to
+				} else {
+					from, to = negative, negative+value
+					negative = to
+				}
+			}
+
+			barX := x
+			if !c.Stacked {
+				barX += barWidth * float64(j)
+			}
+
+			top := math.Min(y(from), y(to))
+			s.printf(
+				`<rect x="%s" y="%s" width="%s" height="%s" fill="%s">`,
+				num(barX), num(top), num(barWidth), num(math.Abs(y(from)-y(to))), templ.EscapeString(series.Color),
+			)
+			s.title(fmt.Sprintf("%s, %s: %s", label, series.Name, c.format(value)))
+			s.printf(`</rect>`)
+		}
+	}
+}
+
+func (c Chart) drawLines(s *svg, band float64, y func(float64) float64) {
+	for _, series := range c.Series {
+		if !series.Line {
+			continue
+		}
+
+		points := ""
+		for i := range c.Labels {
+			points += fmt.Sprintf("%s,%s ", num(marginLeft+band*(float64(i)+0.5)), num(y(series.value(i))))
+		}
+		s.printf(
+			`<polyline points="%s" fill="none" stroke="%s" stroke-width="2" stroke-linejoin="round"></polyline>`,
+			points, templ.EscapeString(series.Color),
+		)
+
+		for i, label := range c.Labels {
+			value := series.value(i)
+			s.printf(
+				`<circle cx="%s" cy="%s" r="3" fill="%s">`,
+				num(marginLeft+band*(float64(i)+0.5)), num(y(value)), templ.EscapeString(series.Color),
+			)
+			s.title(fmt.Sprintf("%s, %s: %s", label, series.Name, c.format(value)))
+			s.printf(`</circle>`)
+		}
+	}
+}
+
+// niceStep rounds the step up to 1, 2 or 5 times a power of ten.
+func niceStep(step float64) float64 {
+	if step <= 0 {
+		return 1
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	for _, factor := range []float64{1, 2, 5} {
+		if step <= factor*magnitude {
+			return factor * magnitude
+		}
+	}
+
+	return 10 * magnitude
+}
+
+// formatTick formats the value with as many decimals as the step has.
+func formatTick(value, step float64) string {
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+
+	return strconv.FormatFloat(value, 'f', decimals, 64)
+}
@@ -0,0 +1,93 @@
+// Package charts renders charts as inline svg on the server, so that they work without
+// javascript or third party scripts, and print like the rest of the page.
+package charts
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+const (
+	fontSize  = 12
+	textColor = "#6b7280"
+	gridColor = "#e5e7eb"
+)
+
+// svg collects the elements of a chart.
+type svg struct {
+	strings.Builder
+}
+
+func (s *svg) printf(format string, args ...any) {
+	fmt.Fprintf(s, format, args...)
+}
+
+// title adds a tooltip to the element it is in.
+func (s *svg) title(text string) {
+	s.printf(`<title>%s</title>`, templ.EscapeString(text))
+}
+
+// text writes the text at the point. Anchor is start, middle or end.
+func (s *svg) text(x, y float64, anchor, text string) {
+	s.printf(
+		`<text x="%s" y="%s" text-anchor="%s" font-size="%d" fill="%s">%s</text>`,
+		num(x), num(y), anchor, fontSize, textColor, templ.EscapeString(text),
+	)
+}
+
+// open starts the svg element of a chart with the size, which scales to the width of the page.
+func (s *svg) open(width, height float64, label string) {
+	s.printf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %s %s" class="w-full h-full" role="img" aria-label="%s" font-family="inherit">`,
+		num(width), num(height), templ.EscapeString(label),
+	)
+}
+
+func (s *svg) close() {
+	s.WriteString(`</svg>`)
+}
+
+// num formats the coordinate with at most two decimals.
+func num(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// legend draws a square of the color followed by the name of each item in a row
+// starting at the point, and wraps to new rows at the width.
+func (s *svg) legend(x, y, width float64, names, colors []string) {
+	startX := x
+	for i, name := range names {
+		itemWidth := float64(len([]rune(name)))*7 + 28
+		if x > startX && x+itemWidth > startX+width {
+			x = startX
+			y += 20
+		}
+
+		s.printf(
+			`<rect x="%s" y="%s" width="12" height="12" rx="2" fill="%s"></rect>`,
+			num(x), num(y-10), templ.EscapeString(colors[i]),
+		)
+		s.text(x+16, y, "start", name)
+		x += itemWidth
+	}
+}
+
+// legendHeight returns the height of the legend drawn at the width.
+func legendHeight(width float64, names []string) float64 {
+	rows := 1
+	x := 0.0
+	for _, name := range names {
+		itemWidth := float64(len([]rune(name)))*7 + 28
+		if x > 0 && x+itemWidth > width {
+			x = 0
+			rows++
+		}
+		x += itemWidth
+	}
+
+	return float64(rows) * 20
+}
@@ -0,0 +1,118 @@
+package charts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/a-h/templ"
+)
+
+// Slice is a part of a pie chart.
+type Slice struct {
+	Label string
+	Value float64
+	// Hex color, like #ef4444.
+	Color string
+}
+
+const (
+	pieSize   = 200
+	pieRadius = 95
+	// Slices smaller than this share of the pie aren't labeled, because the label doesn't fit.
+	minLabeledShare = 0.06
+)
+
+// Pie draws the slices as a pie chart. Slices that aren't positive are left out.
+func Pie(slices []Slice) templ.Component {
+	return pie(slices, 0)
+}
+
+// Donut draws the slices as a pie chart with a hole in the middle.
+func Donut(slices []Slice) templ.Component {
+	return pie(slices, pieRadius*0.55)
+}
+
+func pie(slices []Slice, innerRadius float64) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		total := 0.0
+		for _, slice := range slices {
+			if slice.Value > 0 {
+				total += slice.Value
+			}
+		}
+
+		s := &svg{}
+		s.open(pieSize, pieSize, "Pie chart")
+
+		center := pieSize / 2.0
+		// Angles start at the top and go clockwise.
+		angle := -math.Pi / 2
+		for _, slice := range slices {
+			if slice.Value <= 0 {
+				continue
+			}
+
+			share := slice.Value / total
+			end := angle + share*2*math.Pi
+
+			s.printf(`<path d="%s" fill="%s" stroke="#ffffff" stroke-width="1">`,
+				slicePath(center, innerRadius, angle, end), templ.EscapeString(slice.Color))
+			s.title(fmt.Sprintf("%s: %.0f%%", slice.Label, share*100))
+			s.printf(`</path>`)
+
+			if share >= minLabeledShare {
+				middle := (angle + end) / 2
+				radius := (pieRadius + innerRadius) / 2
+				if innerRadius == 0 {
+					radius = pieRadius * 0.65
+				}
+
+				s.printf(
+					`<text x="%s" y="%s" text-anchor="middle" dominant-baseline="middle" font-size="9" fill="#ffffff" pointer-events="none">%.0f%%</text>`,
+					num(center+radius*math.Cos(middle)), num(center+radius*math.Sin(middle)), share*100,
+				)
+			}
+
+			angle = end
+		}
+
+		s.close()
+		_, err := io.WriteString(w, s.String())
+		return err
+	})
+}
+
+// slicePath returns the path of the slice between the angles. With an inner radius,
+// the slice is cut off towards the center.
+func slicePath(center, innerRadius, start, end float64) string {
+	// A full circle can't be drawn with a single arc, so it is drawn with two halves.
+	if end-start >= 2*math.Pi-1e-9 {
+		middle := start + math.Pi
+		return slicePath(center, innerRadius, start, middle) + " " + slicePath(center, innerRadius, middle, end)
+	}
+
+	point := func(radius, angle float64) string {
+		return num(center+radius*math.Cos(angle)) + " " + num(center+radius*math.Sin(angle))
+	}
+
+	largeArc := 0
+	if end-start > math.Pi {
+		largeArc = 1
+	}
+
+	outer := fmt.Sprintf(
+		"M %s A %s %s 0 %d 1 %s",
+		point(pieRadius, start), num(pieRadius), num(pieRadius), largeArc, point(pieRadius, end),
+	)
+	if innerRadius == 0 {
+		// The outer arc starts with a move, which is replaced by a line from the center.
+		return fmt.Sprintf("M %s L %s Z", point(0, 0), outer[len("M "):])
+	}
+
+	return fmt.Sprintf(
+		"%s L %s A %s %s 0 %d 0 %s Z",
+		outer, point(innerRadius, end), num(innerRadius), num(innerRadius), largeArc, point(innerRadius, start),
+	)
+}
@@ -0,0 +1,23 @@
+package csrf
+
+import "context"
+
+const (
+	HeaderName = "X-CSRF-Token"
+	FormField  = "csrf_token"
+)
+
+type contextKey string
+
+const contextKeyToken = contextKey("csrf_token")
+
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, contextKeyToken, token)
+}
+
+// Token returns the CSRF token for the current request, that has to be sent
+// back with every state changing request.
+func Token(ctx context.Context) string {
+	token, _ := ctx.Value(contextKeyToken).(string)
+	return token
+}
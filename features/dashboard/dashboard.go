@@ -5,8 +5,6 @@ import (
 	"log/slog"
 	"net/http"
 	"sort"
-	"strconv"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/viddrobnic/sparovec/features"
@@ -15,22 +13,22 @@ import (
 )
 
 type Repository interface {
-	GetTransactions(ctx context.Context, walletId, year int, mont time.Month) ([]*models.Transaction, error)
+	GetTagBalances(ctx context.Context, walletId int, dateRange models.DateRange) ([]models.TagBalance, error)
+	GetTotals(ctx context.Context, walletId int, dateRange models.DateRange) (models.Totals, error)
+	GetMonthlyTotals(ctx context.Context, walletId, startDay int, dateRange models.DateRange) ([]models.MonthTotals, error)
+	GetTagSpending(ctx context.Context, walletId, startDay int, dateRange models.DateRange) ([]models.TagSpending, error)
+	GetNameTotals(ctx context.Context, walletId int, dateRange models.DateRange) ([]models.NameTotals, error)
 }
 
 type WalletRepository interface {
 	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
-	HasPermission(ctx context.Context, walletId, userId int) (bool, error)
-}
-
-type TransactionsService interface {
-	ExpandTags(ctx context.Context, transactions []*models.Transaction) error
+	ForId(ctx context.Context, walletId int) (*models.Wallet, error)
+	Role(ctx context.Context, walletId, userId int) (models.Role, error)
 }
 
 type Dashboard struct {
-	repository          Repository
-	walletRepository    WalletRepository
-	transactionsService TransactionsService
+	repository       Repository
+	walletRepository WalletRepository
 
 	log *slog.Logger
 }
@@ -38,13 +36,11 @@ type Dashboard struct {
 func New(
 	repository Repository,
 	walletRepository WalletRepository,
-	transactionsService TransactionsService,
 	log *slog.Logger,
 ) *Dashboard {
 	return &Dashboard{
-		repository:          repository,
-		walletRepository:    walletRepository,
-		transactionsService: transactionsService,
+		repository:       repository,
+		walletRepository: walletRepository,
 
 		log: log,
 	}
@@ -55,19 +51,21 @@ func (d *Dashboard) Mount(router chi.Router) {
 	group.Use(auth.RequiredMiddleware)
 
 	group.Get("/", d.dashboard)
+	group.Get("/trends", d.trends)
+	group.Get("/forecast", d.forecast)
 
 	router.Mount("/wallets/{walletId}", group)
 }
 
-func (d *Dashboard) hasPermission(ctx context.Context, w http.ResponseWriter, walletId, userId int) bool {
-	hasPermission, err := d.walletRepository.HasPermission(ctx, walletId, userId)
+func (d *Dashboard) hasPermission(ctx context.Context, w http.ResponseWriter, walletId, userId int, required models.Role) bool {
+	role, err := d.walletRepository.Role(ctx, walletId, userId)
 	if err != nil {
-		d.log.ErrorContext(ctx, "Failed to get wallet permission", "error", err)
+		d.log.ErrorContext(ctx, "Failed to get wallet role", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return false
 	}
 
-	if !hasPermission {
+	if !role.Includes(required) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return false
 	}
@@ -80,11 +78,22 @@ func (d *Dashboard) dashboard(w http.ResponseWriter, r *http.Request) {
 	walletId := features.GetWalletId(r)
 	user := auth.GetUser(r)
 
-	if !d.hasPermission(ctx, w, walletId, user.Id) {
+	if !d.hasPermission(ctx, w, walletId, user.Id, models.RoleViewer) {
+		return
+	}
+
+	wallet, err := d.walletRepository.ForId(ctx, walletId)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get wallet", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if wallet == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
 		return
 	}
 
-	form := dashboardFormFromRequest(r)
+	form := dashboardFormFromRequest(r, wallet)
 
 	wallets, err := d.walletRepository.ForUser(ctx, user.Id)
 	if err != nil {
@@ -93,15 +102,23 @@ func (d *Dashboard) dashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transactions, err := d.repository.GetTransactions(ctx, walletId, form.year, form.month)
+	totals, err := d.repository.GetTotals(ctx, walletId, form.dateRange)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get totals", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	tagBalances, err := d.repository.GetTagBalances(ctx, walletId, form.dateRange)
 	if err != nil {
-		d.log.ErrorContext(ctx, "Failed to get transactions", "error", err)
+		d.log.ErrorContext(ctx, "Failed to get tag balances", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	err = d.transactionsService.ExpandTags(ctx, transactions)
+
+	comparisons, err := d.comparisons(ctx, wallet, form.dateRange)
 	if err != nil {
-		d.log.ErrorContext(ctx, "Failed to expand tags", "error", err)
+		d.log.ErrorContext(ctx, "Failed to get totals for comparison", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -111,12 +128,14 @@ func (d *Dashboard) dashboard(w http.ResponseWriter, r *http.Request) {
 			SelectedWalletId: walletId,
 			Wallets:          wallets,
 			Username:         user.Username,
+			IsAdmin:          user.IsAdmin,
 			Title:            "Šparovec | Dashboard",
 		},
-		month:   form.month,
-		year:    form.year,
-		maxYear: time.Now().Year(),
-		data:    createDashboardData(transactions),
+		wallet:      wallet,
+		preset:      form.preset,
+		dateRange:   form.dateRange,
+		data:        createDashboardData(walletId, totals, tagBalances),
+		comparisons: comparisons,
 	}
 	view := dashboardView(data)
 	err = view.Render(ctx, w)
@@ -125,71 +144,70 @@ func (d *Dashboard) dashboard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-type dashboardForm struct {
-	year  int
-	month time.Month
+// comparison are the totals of the range moved back in time, like the same month last year.
+type comparison struct {
+	label  string
+	totals models.Totals
 }
 
-func dashboardFormFromRequest(r *http.Request) dashboardForm {
-	form := dashboardForm{
-		year:  time.Now().Year(),
-		month: time.Now().Month(),
+// comparisons returns the totals of the range a month earlier and the same range last year.
+// Open ranges can't be moved, so they aren't compared.
+func (d *Dashboard) comparisons(ctx context.Context, wallet *models.Wallet, dateRange models.DateRange) ([]comparison, error) {
+	if dateRange.From.IsZero() || dateRange.To.IsZero() {
+		return nil, nil
 	}
 
-	yearStr := r.FormValue("year")
-	if yearStr != "" {
-		year, err := strconv.Atoi(yearStr)
-		if err == nil {
-			form.year = year
-		}
+	shifts := []struct {
+		label  string
+		months int
+	}{
+		{monthShiftLabel(wallet, dateRange), -1},
+		{"last year", -12},
 	}
 
-	monthStr := r.FormValue("month")
-	if monthStr != "" {
-		month, err := strconv.Atoi(monthStr)
-		if err == nil {
-			form.month = time.Month(month)
+	comparisons := make([]comparison, len(shifts))
+	for i, shift := range shifts {
+		totals, err := d.repository.GetTotals(ctx, wallet.Id, shiftRange(dateRange, shift.months))
+		if err != nil {
+			return nil, err
 		}
+
+		comparisons[i] = comparison{label: shift.label, totals: totals}
 	}
 
-	return form
+	return comparisons, nil
 }
 
-func createDashboardData(transactions []*models.Transaction) models.DashboardData {
-	data := models.DashboardData{
-		NrTransactions: len(transactions),
-		TagBalance:     []models.TagBalance{},
-	}
-
-	tagBalance := make(map[int]int)
-	tags := make(map[int]*models.Tag)
-
-	for _, tr := range transactions {
-		if tr.Value > 0 {
-			data.Income += tr.Value
-		} else {
-			data.Outcome += tr.Value
-
-			if tr.Tag != nil {
-				tagBalance[tr.Tag.Id] += tr.Value
-				tags[tr.Tag.Id] = tr.Tag
-			} else {
-				tagBalance[0] += tr.Value
-				tags[0] = &models.Tag{
-					Name:     "Other",
-					WalletId: tr.WalletId,
-				}
-			}
-		}
+// monthShiftLabel names the range moved back by a month. Only a range that is exactly one
+// month or wallet period is compared with the previous one, other ranges overlap it or
+// leave gaps.
+func monthShiftLabel(wallet *models.Wallet, dateRange models.DateRange) string {
+	isPeriod := dateRange.From.Day() == max(wallet.PeriodStartDay, 1) &&
+		shiftDay(dateRange.From, 1, false).AddDate(0, 0, -1).Equal(dateRange.To)
+	if !isPeriod {
+		return "a month earlier"
+	}
+	if wallet.PeriodStartDay > 1 {
+		return "previous period"
+	}
+	return "previous month"
+}
 
-		data.Balance += tr.Value
+// createDashboardData sorts the tag balances with the biggest expenses first
+// and shows expenses without a tag as Other.
+func createDashboardData(walletId int, totals models.Totals, tagBalances []models.TagBalance) models.DashboardData {
+	data := models.DashboardData{
+		Income:         totals.Income,
+		Outcome:        totals.Outcome,
+		Balance:        totals.Balance(),
+		NrTransactions: totals.NrTransactions,
+		TagBalance:     tagBalances,
 	}
 
-	for tagId, balance := range tagBalance {
-		data.TagBalance = append(data.TagBalance, models.TagBalance{
-			Tag:     tags[tagId],
-			Balance: balance,
-		})
+	for i := range data.TagBalance {
+		if data.TagBalance[i].Tag == nil {
+			data.TagBalance[i].Tag = models.OtherTag(walletId)
+		}
 	}
 
 	sort.Slice(data.TagBalance, func(i, j int) bool {
@@ -0,0 +1,304 @@
+package dashboard
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viddrobnic/sparovec/features"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const (
+	// historyMonths are the complete months before the current one the forecast is based on.
+	historyMonths = 6
+	// minRecurringMonths is in how many of the history months a transaction has to appear
+	// once to be treated as recurring, like a subscription or a salary.
+	minRecurringMonths = 3
+)
+
+// forecastMonths are the numbers of months the forecast can show.
+var forecastMonths = []int{3, 4, 5, 6}
+
+// recurringTransaction is expected again each month on the day.
+type recurringTransaction struct {
+	name  string
+	tag   *models.Tag
+	day   int
+	value int
+}
+
+// tagAverage is the average monthly sum of transactions with the tag that aren't recurring.
+type tagAverage struct {
+	tag   *models.Tag
+	value int
+}
+
+type forecastDay struct {
+	day     time.Time
+	balance int
+}
+
+type forecast struct {
+	recurring []recurringTransaction
+	averages  []tagAverage
+	days      []forecastDay
+	lowest    forecastDay
+	// Number of history months with data the averages are based on.
+	historyMonths int
+	// First day on which the balance is below the threshold, nil if it stays above.
+	belowThreshold *forecastDay
+}
+
+func (d *Dashboard) forecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	walletId := features.GetWalletId(r)
+	user := auth.GetUser(r)
+
+	if !d.hasPermission(ctx, w, walletId, user.Id, models.RoleViewer) {
+		return
+	}
+
+	months := forecastMonths[0]
+	for _, m := range forecastMonths {
+		if r.FormValue("months") == strconv.Itoa(m) {
+			months = m
+		}
+	}
+
+	// The threshold is given in euros, like other amounts in forms.
+	threshold := 0
+	thresholdValue, err := strconv.ParseFloat(strings.ReplaceAll(r.FormValue("threshold"), ",", "."), 64)
+	if err == nil {
+		threshold = int(math.Round(thresholdValue * 100))
+	}
+
+	wallets, err := d.walletRepository.ForUser(ctx, user.Id)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get user wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	totals, err := d.repository.GetTotals(ctx, walletId, models.DateRange{})
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get totals", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	year, month, day := time.Now().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	history := models.DateRange{
+		From: time.Date(year, month-historyMonths, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(year, month, 0, 0, 0, 0, 0, time.UTC),
+	}
+
+	nameTotals, err := d.repository.GetNameTotals(ctx, walletId, history)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get name totals", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := forecastViewData{
+		navbar: models.Navbar{
+			SelectedWalletId: walletId,
+			Wallets:          wallets,
+			Username:         user.Username,
+			IsAdmin:          user.IsAdmin,
+			Title:            "Šparovec | Forecast",
+		},
+		months:    months,
+		threshold: threshold,
+		balance:   totals.Balance(),
+		forecast:  createForecast(today, months, totals.Balance(), threshold, history, nameTotals),
+	}
+	view := forecastView(data)
+	err = view.Render(ctx, w)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Error rendering forecast view", "error", err)
+	}
+}
+
+// createForecast projects the balance for each day of the next months. Recurring
+// transactions are expected on their day, and the average of other transactions is
+// spread evenly over the days.
+func createForecast(
+	today time.Time,
+	months int,
+	balance int,
+	threshold int,
+	history models.DateRange,
+	nameTotals []models.NameTotals,
+) forecast {
+	type monthTotals struct {
+		nrTransactions int
+		value          int
+		lastDay        int
+	}
+
+	// Transactions with the same name are grouped by month to find recurring ones.
+	byName := make(map[string]map[time.Time]*monthTotals)
+	latest := make(map[string]models.NameTotals)
+	for _, totals := range nameTotals {
+		key := strings.ToLower(strings.TrimSpace(totals.Name))
+		if byName[key] == nil {
+			byName[key] = make(map[time.Time]*monthTotals)
+		}
+
+		month := byName[key][totals.Month]
+		if month == nil {
+			month = &monthTotals{}
+			byName[key][totals.Month] = month
+		}
+		month.nrTransactions += totals.NrTransactions
+		month.value += totals.Value
+		month.lastDay = max(month.lastDay, totals.LastDay)
+
+		if !totals.Month.Before(latest[key].Month) {
+			latest[key] = totals
+		}
+	}
+
+	result := forecast{}
+	recurringKeys := make(map[string]bool)
+	// Recurring transactions have to still be going on in one of the last two months.
+	activeSince := time.Date(history.To.Year(), history.To.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	for key, byMonth := range byName {
+		once := true
+		for _, month := range byMonth {
+			once = once && month.nrTransactions == 1
+		}
+
+		last := latest[key]
+		if !once || len(byMonth) < minRecurringMonths || last.Month.Before(activeSince) {
+			continue
+		}
+
+		recurringKeys[key] = true
+		lastMonth := byMonth[last.Month]
+		result.recurring = append(result.recurring, recurringTransaction{
+			name:  strings.TrimSpace(last.Name),
+			tag:   last.Tag,
+			day:   lastMonth.lastDay,
+			value: lastMonth.value,
+		})
+	}
+
+	sort.Slice(result.recurring, func(i, j int) bool {
+		if result.recurring[i].day == result.recurring[j].day {
+			return result.recurring[i].name < result.recurring[j].name
+		}
+		return result.recurring[i].day < result.recurring[j].day
+	})
+
+	tagSums := make(map[int]*tagAverage)
+	for _, totals := range nameTotals {
+		if recurringKeys[strings.ToLower(strings.TrimSpace(totals.Name))] {
+			continue
+		}
+
+		tag := totals.Tag
+		if tag == nil {
+			tag = models.OtherTag(0)
+		}
+
+		average, ok := tagSums[tag.Id]
+		if !ok {
+			average = &tagAverage{tag: tag}
+			tagSums[tag.Id] = average
+		}
+		average.value += totals.Value
+	}
+
+	result.historyMonths = monthsWithData(history, nameTotals)
+
+	variable := 0
+	for _, average := range tagSums {
+		average.value /= result.historyMonths
+		variable += average.value
+		result.averages = append(result.averages, *average)
+	}
+
+	// Biggest expenses first, income last.
+	sort.Slice(result.averages, func(i, j int) bool {
+		if result.averages[i].value == result.averages[j].value {
+			return result.averages[i].tag.Name < result.averages[j].tag.Name
+		}
+		return result.averages[i].value < result.averages[j].value
+	})
+
+	perDay := float64(variable) * 12 / 365
+	projected := float64(balance)
+	result.lowest = forecastDay{day: today, balance: balance}
+	if balance < threshold {
+		first := result.lowest
+		result.belowThreshold = &first
+	}
+	for day := today.AddDate(0, 0, 1); !day.After(today.AddDate(0, months, 0)); day = day.AddDate(0, 0, 1) {
+		projected += perDay
+
+		lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		for _, recurring := range result.recurring {
+			if day.Day() == min(recurring.day, lastDay) {
+				projected += float64(recurring.value)
+			}
+		}
+
+		projectedDay := forecastDay{day: day, balance: int(math.Round(projected))}
+		result.days = append(result.days, projectedDay)
+
+		if projectedDay.balance < result.lowest.balance {
+			result.lowest = projectedDay
+		}
+		if projectedDay.balance < threshold && result.belowThreshold == nil {
+			result.belowThreshold = &projectedDay
+		}
+	}
+
+	return result
+}
+
+// monthsWithData returns the number of history months since the first one with transactions,
+// at least one. Averaging over all history months would under-project the spending of new wallets.
+func monthsWithData(history models.DateRange, nameTotals []models.NameTotals) int {
+	first := history.To
+	for _, totals := range nameTotals {
+		if totals.Month.Before(first) {
+			first = totals.Month
+		}
+	}
+
+	months := (history.To.Year()-first.Year())*12 + int(history.To.Month()-first.Month()) + 1
+	return min(max(months, 1), historyMonths)
+}
+
+func (f forecast) historyLabel() string {
+	if f.historyMonths == 1 {
+		return "the last month"
+	}
+	return "the last " + strconv.Itoa(f.historyMonths) + " months"
+}
+
+// recurringTotal returns the monthly sum of recurring transactions.
+func (f forecast) recurringTotal() int {
+	total := 0
+	for _, recurring := range f.recurring {
+		total += recurring.value
+	}
+	return total
+}
+
+// averageTotal returns the average monthly sum of other transactions.
+func (f forecast) averageTotal() int {
+	total := 0
+	for _, average := range f.averages {
+		total += average.value
+	}
+	return total
+}
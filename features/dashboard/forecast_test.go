@@ -0,0 +1,178 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// monthlyTotals returns totals of one transaction with the name in each of the months.
+func monthlyTotals(name string, value int, months ...time.Time) []models.NameTotals {
+	var totals []models.NameTotals
+	for _, month := range months {
+		totals = append(totals, models.NameTotals{
+			Name:           name,
+			Month:          month,
+			NrTransactions: 1,
+			Value:          value,
+			LastDay:        5,
+		})
+	}
+	return totals
+}
+
+func TestCreateForecastRecurring(t *testing.T) {
+	tests := []struct {
+		name string
+		// First day of the current month.
+		today time.Time
+		// Months in which the transaction last appeared, oldest first.
+		months    []time.Time
+		recurring bool
+	}{
+		{
+			name:      "last month after 31-day month",
+			today:     date(2024, time.August, 1),
+			months:    []time.Time{date(2024, time.May, 1), date(2024, time.June, 1), date(2024, time.July, 1)},
+			recurring: true,
+		},
+		{
+			name:      "second to last month after 31-day month",
+			today:     date(2024, time.August, 1),
+			months:    []time.Time{date(2024, time.April, 1), date(2024, time.May, 1), date(2024, time.June, 1)},
+			recurring: true,
+		},
+		{
+			name:      "third to last month after 31-day month",
+			today:     date(2024, time.August, 1),
+			months:    []time.Time{date(2024, time.March, 1), date(2024, time.April, 1), date(2024, time.May, 1)},
+			recurring: false,
+		},
+		{
+			name:      "second to last month after 30-day month",
+			today:     date(2024, time.July, 1),
+			months:    []time.Time{date(2024, time.March, 1), date(2024, time.April, 1), date(2024, time.May, 1)},
+			recurring: true,
+		},
+		{
+			name:      "third to last month after 30-day month",
+			today:     date(2024, time.July, 1),
+			months:    []time.Time{date(2024, time.February, 1), date(2024, time.March, 1), date(2024, time.April, 1)},
+			recurring: false,
+		},
+		{
+			name:      "second to last month over new year",
+			today:     date(2025, time.January, 1),
+			months:    []time.Time{date(2024, time.September, 1), date(2024, time.October, 1), date(2024, time.November, 1)},
+			recurring: true,
+		},
+		{
+			name:      "second to last month after february",
+			today:     date(2024, time.March, 1),
+			months:    []time.Time{date(2023, time.November, 1), date(2023, time.December, 1), date(2024, time.January, 1)},
+			recurring: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			history := models.DateRange{
+				From: test.today.AddDate(0, -historyMonths, 0),
+				To:   test.today.AddDate(0, 0, -1),
+			}
+			nameTotals := monthlyTotals("Rent", -50000, test.months...)
+
+			result := createForecast(test.today, 3, 0, 0, history, nameTotals)
+			if got := len(result.recurring) == 1; got != test.recurring {
+				t.Errorf("recurring = %v, want %v", got, test.recurring)
+			}
+		})
+	}
+}
+
+func TestCreateForecastBelowThreshold(t *testing.T) {
+	today := date(2024, time.August, 1)
+	history := models.DateRange{From: date(2024, time.February, 1), To: date(2024, time.July, 31)}
+	nameTotals := monthlyTotals("Rent", -50000, date(2024, time.May, 1), date(2024, time.June, 1), date(2024, time.July, 1))
+
+	// The balance is already below the threshold, and keeps decreasing.
+	result := createForecast(today, 3, 1000, 2000, history, nameTotals)
+	if result.belowThreshold == nil {
+		t.Fatal("belowThreshold = nil, want today")
+	}
+	if !result.belowThreshold.day.Equal(today) || result.belowThreshold.balance != 1000 {
+		t.Errorf("belowThreshold = %v, want %v with balance 1000", result.belowThreshold.day, today)
+	}
+	if result.lowest.balance >= 1000 {
+		t.Errorf("lowest balance = %d, want less than 1000", result.lowest.balance)
+	}
+}
+
+func TestCreateForecastAverages(t *testing.T) {
+	today := date(2024, time.August, 1)
+	history := models.DateRange{From: date(2024, time.February, 1), To: date(2024, time.July, 31)}
+	groceries := func(month time.Time, value int) models.NameTotals {
+		return models.NameTotals{Name: "Groceries", Month: month, NrTransactions: 4, Value: value, LastDay: 28}
+	}
+
+	tests := []struct {
+		name          string
+		nameTotals    []models.NameTotals
+		historyMonths int
+		average       int
+	}{
+		{
+			name: "full history",
+			nameTotals: []models.NameTotals{
+				groceries(date(2024, time.February, 1), -6000),
+				groceries(date(2024, time.July, 1), -6000),
+			},
+			historyMonths: 6,
+			average:       -2000,
+		},
+		{
+			name: "new wallet",
+			nameTotals: []models.NameTotals{
+				groceries(date(2024, time.June, 1), -5000),
+				groceries(date(2024, time.July, 1), -7000),
+			},
+			historyMonths: 2,
+			average:       -6000,
+		},
+		{
+			name: "gap in history",
+			nameTotals: []models.NameTotals{
+				groceries(date(2024, time.May, 1), -9000),
+				groceries(date(2024, time.July, 1), -9000),
+			},
+			historyMonths: 3,
+			average:       -6000,
+		},
+		{
+			name:          "no history",
+			historyMonths: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := createForecast(today, 3, 0, 0, history, test.nameTotals)
+			if result.historyMonths != test.historyMonths {
+				t.Errorf("historyMonths = %d, want %d", result.historyMonths, test.historyMonths)
+			}
+
+			average := 0
+			for _, tagAverage := range result.averages {
+				average += tagAverage.value
+			}
+			if average != test.average {
+				t.Errorf("average = %d, want %d", average, test.average)
+			}
+		})
+	}
+}
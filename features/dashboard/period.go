@@ -0,0 +1,173 @@
+package dashboard
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const dateFormat = "2006-01-02"
+
+type preset string
+
+const (
+	presetThisMonth   preset = "this-month"
+	presetLastMonth   preset = "last-month"
+	presetThisWeek    preset = "this-week"
+	presetLast30Days  preset = "last-30-days"
+	presetThisQuarter preset = "this-quarter"
+	presetYearToDate  preset = "year-to-date"
+	presetLastYear    preset = "last-year"
+	presetAllTime     preset = "all-time"
+	// presetCustom is a range with from and to chosen by the user.
+	presetCustom preset = "custom"
+)
+
+type presetOption struct {
+	Preset preset
+	Label  string
+}
+
+var presetOptions = []presetOption{
+	{presetThisMonth, "This month"},
+	{presetLastMonth, "Last month"},
+	{presetThisWeek, "This week"},
+	{presetLast30Days, "Last 30 days"},
+	{presetThisQuarter, "This quarter"},
+	{presetYearToDate, "Year to date"},
+	{presetLastYear, "Last year"},
+	{presetAllTime, "All time"},
+	{presetCustom, "Custom range"},
+}
+
+// label returns the label of the option. Month presets are called periods when the
+// periods of the wallet don't start on the first day of the month.
+func (o presetOption) label(wallet *models.Wallet) string {
+	if wallet.PeriodStartDay > 1 {
+		switch o.Preset {
+		case presetThisMonth:
+			return "This period"
+		case presetLastMonth:
+			return "Last period"
+		}
+	}
+
+	return o.Label
+}
+
+func (p preset) isValid() bool {
+	for _, option := range presetOptions {
+		if option.Preset == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dateRange returns the range of the preset on the day today. Month presets follow the
+// periods of the wallet. The custom preset has no range.
+func (p preset) dateRange(today time.Time, wallet *models.Wallet) models.DateRange {
+	year, month, day := today.Date()
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	today = date(year, month, day)
+
+	switch p {
+	case presetThisMonth:
+		return wallet.Period(today)
+	case presetLastMonth:
+		current := wallet.Period(today)
+		return wallet.Period(current.From.AddDate(0, 0, -1))
+	case presetThisWeek:
+		// Weeks start on monday.
+		weekday := (int(today.Weekday()) + 6) % 7
+		monday := today.AddDate(0, 0, -weekday)
+		return models.DateRange{From: monday, To: monday.AddDate(0, 0, 6)}
+	case presetLast30Days:
+		return models.DateRange{From: today.AddDate(0, 0, -29), To: today}
+	case presetThisQuarter:
+		firstMonth := month - (month-1)%3
+		return models.DateRange{From: date(year, firstMonth, 1), To: date(year, firstMonth+3, 0)}
+	case presetYearToDate:
+		return models.DateRange{From: date(year, time.January, 1), To: today}
+	case presetLastYear:
+		return models.DateRange{From: date(year-1, time.January, 1), To: date(year-1, time.December, 31)}
+	default:
+		return models.DateRange{}
+	}
+}
+
+type dashboardForm struct {
+	preset    preset
+	dateRange models.DateRange
+}
+
+// dashboardFormFromRequest reads the range from the preset, or from the from and to dates
+// of a custom range. Links with a year and month show the period of the wallet that starts
+// in that month.
+func dashboardFormFromRequest(r *http.Request, wallet *models.Wallet) dashboardForm {
+	today := time.Now()
+
+	year, yearErr := strconv.Atoi(r.FormValue("year"))
+	month, monthErr := strconv.Atoi(r.FormValue("month"))
+	if yearErr == nil && monthErr == nil && month >= 1 && month <= 12 {
+		return dashboardForm{
+			preset:    presetCustom,
+			dateRange: wallet.Period(time.Date(year, time.Month(month), 28, 0, 0, 0, 0, time.UTC)),
+		}
+	}
+
+	p := preset(r.FormValue("preset"))
+	if !p.isValid() {
+		p = presetThisMonth
+	}
+
+	if p != presetCustom {
+		return dashboardForm{preset: p, dateRange: p.dateRange(today, wallet)}
+	}
+
+	// Missing dates leave the range open on that side.
+	from, _ := time.Parse(dateFormat, r.FormValue("from"))
+	to, _ := time.Parse(dateFormat, r.FormValue("to"))
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		from, to = to, from
+	}
+
+	return dashboardForm{
+		preset:    presetCustom,
+		dateRange: models.DateRange{From: from, To: to},
+	}
+}
+
+// shiftDay moves the day by the number of months. Days that the target month doesn't have
+// become its last day. With keepLast, the last day of a month stays the last day, so that
+// ranges ending with a month end with the shifted month too.
+func shiftDay(day time.Time, months int, keepLast bool) time.Time {
+	year, month, dayOfMonth := day.Date()
+	lastDay := time.Date(year, month+time.Month(months)+1, 0, 0, 0, 0, 0, time.UTC)
+
+	isLast := day.AddDate(0, 0, 1).Day() == 1
+	if (keepLast && isLast) || dayOfMonth > lastDay.Day() {
+		return lastDay
+	}
+
+	return time.Date(year, month+time.Month(months), dayOfMonth, 0, 0, 0, 0, time.UTC)
+}
+
+// shiftRange moves the range by the number of months, like the same month last year.
+// Open ranges stay open.
+func shiftRange(dateRange models.DateRange, months int) models.DateRange {
+	shifted := dateRange
+	if !dateRange.From.IsZero() {
+		shifted.From = shiftDay(dateRange.From, months, false)
+	}
+	if !dateRange.To.IsZero() {
+		shifted.To = shiftDay(dateRange.To, months, true)
+	}
+
+	return shifted
+}
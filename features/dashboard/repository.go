@@ -2,12 +2,13 @@ package dashboard
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"strconv"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/database"
 	"github.com/viddrobnic/sparovec/models"
 )
 
@@ -19,30 +20,290 @@ func NewRepository(db *sqlx.DB) *RepositoryImpl {
 	return &RepositoryImpl{db: db}
 }
 
-func (r *RepositoryImpl) GetTransactions(ctx context.Context, walletId, year int, month time.Month) ([]*models.Transaction, error) {
-	builder := sq.Select("*").
+// periodColumn selects the first month of the wallet period of the transaction, like 2026-03.
+// Moving the day back by the start day makes periods line up with calendar months.
+func periodColumn(column string, startDay int) sq.Sqlizer {
+	return sq.Expr(
+		fmt.Sprintf("strftime('%%Y-%%m', substr(%s, 1, 10), ?) AS month", column),
+		fmt.Sprintf("-%d days", startDay-1),
+	)
+}
+
+// periodStart returns the first day of the period in the month, like 2026-03.
+func periodStart(month string, startDay int) (time.Time, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return start.AddDate(0, 0, startDay-1), nil
+}
+
+const (
+	incomeColumn  = "COALESCE(SUM(CASE WHEN value > 0 THEN value END), 0) AS income"
+	outcomeColumn = "COALESCE(SUM(CASE WHEN value < 0 THEN value END), 0) AS outcome"
+)
+
+// tagColumns select the tag joined to expenses, which is null for expenses without a tag.
+var tagColumns = []string{"t.id", "t.name", "t.color", "t.icon"}
+
+// tagRow is a tag selected with tagColumns.
+type tagRow struct {
+	Id    sql.NullInt64
+	Name  sql.NullString
+	Color sql.NullString
+	Icon  sql.NullString
+}
+
+// expensesByTag selects expenses of the wallet in the range joined with their tags.
+// Expenses without a tag, or with a tag in the trash, have no tag.
+func expensesByTag(columns []sq.Sqlizer, walletId int, dateRange models.DateRange) sq.SelectBuilder {
+	builder := sq.Select()
+	for _, column := range columns {
+		builder = builder.Column(column)
+	}
+
+	builder = builder.
+		Columns(tagColumns...).
+		Column("SUM(tr.value) AS outcome").
+		From("transactions tr").
+		LeftJoin("tags t ON t.id = tr.tag_id AND t.deleted_at IS NULL").
+		Where(sq.Eq{
+			"tr.wallet_id":  walletId,
+			"tr.deleted_at": nil,
+		}).
+		Where("tr.value < 0")
+
+	return database.InRange(builder, "tr.created_at", dateRange)
+}
+
+// rowTags returns the tags of the rows, sharing tags with the same id, or nil for rows without a tag.
+func rowTags(rows []tagRow, walletId int) []*models.Tag {
+	byId := make(map[int64]*models.Tag)
+	tags := make([]*models.Tag, len(rows))
+	for i, row := range rows {
+		if !row.Id.Valid {
+			continue
+		}
+
+		tag, ok := byId[row.Id.Int64]
+		if !ok {
+			tag = &models.Tag{
+				Id:       int(row.Id.Int64),
+				WalletId: walletId,
+				Name:     row.Name.String,
+				Color:    row.Color.String,
+				Icon:     row.Icon.String,
+			}
+			byId[row.Id.Int64] = tag
+		}
+		tags[i] = tag
+	}
+
+	return tags
+}
+
+// GetTotals returns the sums of the transactions of the wallet made on the days in the range.
+func (r *RepositoryImpl) GetTotals(ctx context.Context, walletId int, dateRange models.DateRange) (models.Totals, error) {
+	builder := sq.Select(incomeColumn, outcomeColumn, "COUNT(*) AS nr_transactions").
 		From("transactions").
 		Where(sq.Eq{
-			"wallet_id":                  walletId,
-			"STRFTIME('%Y', created_at)": strconv.Itoa(year),
-			"STRFTIME('%m', created_at)": fmt.Sprintf("%02d", month),
+			"wallet_id":  walletId,
+			"deleted_at": nil,
 		})
+	builder = database.InRange(builder, "created_at", dateRange)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return models.Totals{}, err
+	}
+
+	var totals models.Totals
+	err = r.db.GetContext(ctx, &totals, stmt, args...)
+	return totals, err
+}
+
+// GetMonthlyTotals returns the sums of the transactions of the wallet for each period
+// starting on the start day. Periods without transactions are left out.
+func (r *RepositoryImpl) GetMonthlyTotals(ctx context.Context, walletId, startDay int, dateRange models.DateRange) ([]models.MonthTotals, error) {
+	builder := sq.Select().
+		Column(periodColumn("created_at", startDay)).
+		Columns(incomeColumn, outcomeColumn).
+		From("transactions").
+		Where(sq.Eq{
+			"wallet_id":  walletId,
+			"deleted_at": nil,
+		}).
+		GroupBy("month").
+		OrderBy("month")
+	builder = database.InRange(builder, "created_at", dateRange)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		Month   string
+		Income  int
+		Outcome int
+	}{}
+	err = r.db.SelectContext(ctx, &rows, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]models.MonthTotals, len(rows))
+	for i, row := range rows {
+		month, err := periodStart(row.Month, startDay)
+		if err != nil {
+			return nil, err
+		}
+
+		totals[i] = models.MonthTotals{
+			Month:   month,
+			Income:  row.Income,
+			Outcome: row.Outcome,
+		}
+	}
+
+	return totals, nil
+}
+
+// GetTagBalances returns the sums of expenses of the wallet in the range for each tag.
+// Expenses without a tag, or with a tag in the trash, have no tag.
+func (r *RepositoryImpl) GetTagBalances(ctx context.Context, walletId int, dateRange models.DateRange) ([]models.TagBalance, error) {
+	builder := expensesByTag(nil, walletId, dateRange).GroupBy("t.id")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		tagRow
+		Outcome int
+	}{}
+	err = r.db.SelectContext(ctx, &rows, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tagRows := make([]tagRow, len(rows))
+	for i, row := range rows {
+		tagRows[i] = row.tagRow
+	}
+
+	balances := make([]models.TagBalance, len(rows))
+	for i, tag := range rowTags(tagRows, walletId) {
+		balances[i] = models.TagBalance{Tag: tag, Balance: rows[i].Outcome}
+	}
+
+	return balances, nil
+}
+
+// GetTagSpending returns the sums of expenses of the wallet for each tag and period
+// starting on the start day. Expenses without a tag, or with a tag in the trash, have no tag.
+func (r *RepositoryImpl) GetTagSpending(ctx context.Context, walletId, startDay int, dateRange models.DateRange) ([]models.TagSpending, error) {
+	columns := []sq.Sqlizer{periodColumn("tr.created_at", startDay)}
+	builder := expensesByTag(columns, walletId, dateRange).
+		GroupBy("month", "t.id").
+		OrderBy("month")
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
-	dbTransactions := []*models.DbTransaction{}
-	err = r.db.SelectContext(ctx, &dbTransactions, stmt, args...)
+	rows := []struct {
+		tagRow
+		Month   string
+		Outcome int
+	}{}
+	err = r.db.SelectContext(ctx, &rows, stmt, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	transactions := make([]*models.Transaction, len(dbTransactions))
-	for i, dbTransaction := range dbTransactions {
-		transactions[i] = dbTransaction.ToModel()
+	tagRows := make([]tagRow, len(rows))
+	for i, row := range rows {
+		tagRows[i] = row.tagRow
+	}
+
+	spending := make([]models.TagSpending, len(rows))
+	for i, tag := range rowTags(tagRows, walletId) {
+		month, err := periodStart(rows[i].Month, startDay)
+		if err != nil {
+			return nil, err
+		}
+
+		spending[i] = models.TagSpending{Month: month, Tag: tag, Outcome: rows[i].Outcome}
+	}
+
+	return spending, nil
+}
+
+// GetNameTotals returns the sums of the transactions of the wallet in the range for each
+// name, tag and calendar month.
+func (r *RepositoryImpl) GetNameTotals(ctx context.Context, walletId int, dateRange models.DateRange) ([]models.NameTotals, error) {
+	builder := sq.Select("lower(trim(tr.name)) AS name_key", "MIN(tr.name) AS transaction_name").
+		Column(periodColumn("tr.created_at", 1)).
+		Columns(tagColumns...).
+		Columns(
+			"COUNT(*) AS nr_transactions",
+			"SUM(tr.value) AS value",
+			"MAX(CAST(substr(tr.created_at, 9, 2) AS INTEGER)) AS last_day",
+		).
+		From("transactions tr").
+		LeftJoin("tags t ON t.id = tr.tag_id AND t.deleted_at IS NULL").
+		Where(sq.Eq{
+			"tr.wallet_id":  walletId,
+			"tr.deleted_at": nil,
+		}).
+		GroupBy("name_key", "month", "t.id").
+		OrderBy("month")
+	builder = database.InRange(builder, "tr.created_at", dateRange)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		tagRow
+		NameKey        string `db:"name_key"`
+		Name           string `db:"transaction_name"`
+		Month          string
+		NrTransactions int `db:"nr_transactions"`
+		Value          int
+		LastDay        int `db:"last_day"`
+	}{}
+	err = r.db.SelectContext(ctx, &rows, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tagRows := make([]tagRow, len(rows))
+	for i, row := range rows {
+		tagRows[i] = row.tagRow
+	}
+
+	totals := make([]models.NameTotals, len(rows))
+	for i, tag := range rowTags(tagRows, walletId) {
+		month, err := periodStart(rows[i].Month, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		totals[i] = models.NameTotals{
+			Name:           rows[i].Name,
+			Tag:            tag,
+			Month:          month,
+			NrTransactions: rows[i].NrTransactions,
+			Value:          rows[i].Value,
+			LastDay:        rows[i].LastDay,
+		}
 	}
 
-	return transactions, nil
+	return totals, nil
 }
@@ -0,0 +1,168 @@
+package dashboard
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/viddrobnic/sparovec/features"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+// trendMonths are the numbers of periods the trends can show.
+var trendMonths = []int{12, 24}
+
+// periodTrend are the totals of one period of the wallet.
+type periodTrend struct {
+	models.MonthTotals
+	dateRange models.DateRange
+}
+
+// tagTrend are the expenses with the tag in each period of the trends.
+type tagTrend struct {
+	tag     *models.Tag
+	outcome []int
+	total   int
+}
+
+func (d *Dashboard) trends(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	walletId := features.GetWalletId(r)
+	user := auth.GetUser(r)
+
+	if !d.hasPermission(ctx, w, walletId, user.Id, models.RoleViewer) {
+		return
+	}
+
+	months := trendMonths[0]
+	for _, m := range trendMonths {
+		if r.FormValue("months") == strconv.Itoa(m) {
+			months = m
+		}
+	}
+
+	wallet, err := d.walletRepository.ForId(ctx, walletId)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get wallet", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if wallet == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	wallets, err := d.walletRepository.ForUser(ctx, user.Id)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get user wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// The last period is the current one.
+	current := wallet.Period(time.Now())
+	first := current.From.AddDate(0, -(months - 1), 0)
+	dateRange := models.DateRange{From: first, To: current.To}
+
+	monthlyTotals, err := d.repository.GetMonthlyTotals(ctx, walletId, wallet.PeriodStartDay, dateRange)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get monthly totals", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	tagSpending, err := d.repository.GetTagSpending(ctx, walletId, wallet.PeriodStartDay, dateRange)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Failed to get tag spending", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	periods, tags := createTrends(wallet, first, months, monthlyTotals, tagSpending)
+
+	data := trendsViewData{
+		navbar: models.Navbar{
+			SelectedWalletId: walletId,
+			Wallets:          wallets,
+			Username:         user.Username,
+			IsAdmin:          user.IsAdmin,
+			Title:            "Šparovec | Trends",
+		},
+		wallet:  wallet,
+		months:  months,
+		periods: periods,
+		tags:    tags,
+	}
+	view := trendsView(data)
+	err = view.Render(ctx, w)
+	if err != nil {
+		d.log.ErrorContext(ctx, "Error rendering trends view", "error", err)
+	}
+}
+
+// createTrends lays out the totals over the periods starting with first,
+// so that periods without transactions are shown too.
+func createTrends(
+	wallet *models.Wallet,
+	first time.Time,
+	months int,
+	monthlyTotals []models.MonthTotals,
+	tagSpending []models.TagSpending,
+) ([]periodTrend, []*tagTrend) {
+	periods := make([]periodTrend, months)
+	periodIndex := make(map[time.Time]int)
+	for i := range periods {
+		start := first.AddDate(0, i, 0)
+		periods[i] = periodTrend{
+			MonthTotals: models.MonthTotals{Month: start},
+			dateRange:   wallet.Period(start),
+		}
+		periodIndex[start] = i
+	}
+
+	for _, totals := range monthlyTotals {
+		if i, ok := periodIndex[totals.Month]; ok {
+			periods[i].MonthTotals = totals
+		}
+	}
+
+	tagTrends := make(map[int]*tagTrend)
+	for _, spending := range tagSpending {
+		i, ok := periodIndex[spending.Month]
+		if !ok {
+			continue
+		}
+
+		tag := spending.Tag
+		if tag == nil {
+			tag = models.OtherTag(wallet.Id)
+		}
+
+		trend, ok := tagTrends[tag.Id]
+		if !ok {
+			trend = &tagTrend{tag: tag, outcome: make([]int, months)}
+			tagTrends[tag.Id] = trend
+		}
+
+		trend.outcome[i] += spending.Outcome
+		trend.total += spending.Outcome
+	}
+
+	tags := make([]*tagTrend, 0, len(tagTrends))
+	for _, trend := range tagTrends {
+		tags = append(tags, trend)
+	}
+
+	// Biggest expenses first.
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].total == tags[j].total {
+			return tags[i].tag.Name < tags[j].tag.Name
+		}
+
+		return tags[i].total < tags[j].total
+	})
+
+	return periods, tags
+}
@@ -1,6 +1,11 @@
 package htmx
 
+import "encoding/json"
+
 const (
+	HeaderRequest            = "HX-Request"
+	HeaderRedirect           = "HX-Redirect"
+	HeaderRefresh            = "HX-Refresh"
 	HeaderTriggerAfterSettle = "HX-Trigger-After-Settle"
 	HeaderReswap             = "HX-Reswap"
 )
@@ -16,6 +21,22 @@ type EventSaveError struct {
 	ErrorMessage string `json:"saveError"`
 }
 
+// EventShowUndo shows a toast with a button that posts to Url to undo the deletion.
+type EventShowUndo struct {
+	Message string `json:"message"`
+	Url     string `json:"url"`
+}
+
+// DeleteWithUndo returns the trigger header value for a successful deletion
+// that can be undone.
+func DeleteWithUndo(message, url string) (string, error) {
+	events, err := json.Marshal(map[string]any{
+		EventDeleteSuccess: nil,
+		"showUndo":         EventShowUndo{Message: message, Url: url},
+	})
+	return string(events), err
+}
+
 const (
 	SwapNone = "none"
 )
@@ -0,0 +1,144 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+type WalletRepository interface {
+	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
+	InvitationsForUser(ctx context.Context, userId int) ([]*models.Invitation, error)
+	CountInvitationsForUser(ctx context.Context, userId int) (int, error)
+	AcceptInvitation(ctx context.Context, invitationId, userId int) (*models.Invitation, error)
+	DeclineInvitation(ctx context.Context, invitationId, userId int) error
+}
+
+type Notifications struct {
+	walletRepository WalletRepository
+
+	log *slog.Logger
+}
+
+func New(walletRepository WalletRepository, log *slog.Logger) *Notifications {
+	return &Notifications{
+		walletRepository: walletRepository,
+		log:              log,
+	}
+}
+
+func (n *Notifications) Mount(router chi.Router) {
+	group := chi.NewRouter()
+	group.Use(auth.RequiredMiddleware)
+
+	group.Get("/", n.notifications)
+	group.Get("/badge", n.badge)
+	group.Post("/invitations/accept", n.acceptInvitation)
+	group.Post("/invitations/decline", n.declineInvitation)
+
+	router.Mount("/notifications", group)
+}
+
+func (n *Notifications) notifications(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	wallets, err := n.walletRepository.ForUser(ctx, user.Id)
+	if err != nil {
+		n.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	invitations, err := n.walletRepository.InvitationsForUser(ctx, user.Id)
+	if err != nil {
+		n.log.ErrorContext(ctx, "Failed to get invitations", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	navbar := models.Navbar{
+		Wallets:  wallets,
+		Username: user.Username,
+		IsAdmin:  user.IsAdmin,
+		Title:    "Šparovec | Notifications",
+	}
+
+	view := notificationsView(navbar, invitations)
+	err = view.Render(ctx, w)
+	if err != nil {
+		n.log.ErrorContext(ctx, "Failed to render notifications view", "error", err)
+	}
+}
+
+// badge renders the number of notifications shown in the navbar.
+func (n *Notifications) badge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	count, err := n.walletRepository.CountInvitationsForUser(ctx, user.Id)
+	if err != nil {
+		n.log.ErrorContext(ctx, "Failed to count invitations", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	view := badgeView(count)
+	err = view.Render(ctx, w)
+	if err != nil {
+		n.log.ErrorContext(ctx, "Failed to render badge view", "error", err)
+	}
+}
+
+func (n *Notifications) acceptInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	invitation, err := n.walletRepository.AcceptInvitation(ctx, id, user.Id)
+	if err != nil {
+		n.log.ErrorContext(ctx, "Failed to accept invitation", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// The invitation was canceled or already answered.
+	if invitation == nil {
+		http.Redirect(w, r, "/notifications", http.StatusSeeOther)
+		return
+	}
+
+	n.log.InfoContext(ctx, "Accepted wallet invitation", "wallet_id", invitation.WalletId, "user_id", user.Id)
+	http.Redirect(w, r, fmt.Sprintf("/wallets/%d", invitation.WalletId), http.StatusSeeOther)
+}
+
+func (n *Notifications) declineInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	err = n.walletRepository.DeclineInvitation(ctx, id, user.Id)
+	if err != nil {
+		n.log.ErrorContext(ctx, "Failed to decline invitation", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/notifications", http.StatusSeeOther)
+}
@@ -0,0 +1,179 @@
+package reports
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/viddrobnic/sparovec/models"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	pdfFont       = "Helvetica"
+	pdfRowHeight  = 6
+	pdfPageMargin = 15
+)
+
+// pdfText encodes the text for the core pdf fonts, which only have the windows-1252 characters.
+// Other letters lose their accents, like č becoming c.
+func pdfText(text string) string {
+	var encoded strings.Builder
+	for _, r := range text {
+		if r == '−' {
+			r = '-'
+		}
+
+		b, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			b, ok = charmap.Windows1252.EncodeRune([]rune(norm.NFD.String(string(r)))[0])
+		}
+		if !ok {
+			b = '?'
+		}
+
+		encoded.WriteByte(b)
+	}
+
+	return encoded.String()
+}
+
+// pdfTable is a table that repeats its header on each page.
+type pdfTable struct {
+	pdf     *gofpdf.Fpdf
+	headers []string
+	widths  []float64
+	// L for text and R for numbers.
+	aligns []string
+}
+
+func (t *pdfTable) header() {
+	t.pdf.SetFont(pdfFont, "B", 9)
+	for i, header := range t.headers {
+		t.pdf.CellFormat(t.widths[i], pdfRowHeight, pdfText(header), "B", 0, t.aligns[i], false, 0, "")
+	}
+	t.pdf.Ln(-1)
+	t.pdf.SetFont(pdfFont, "", 9)
+}
+
+func (t *pdfTable) row(cells ...string) {
+	_, pageHeight := t.pdf.GetPageSize()
+	if t.pdf.GetY()+pdfRowHeight > pageHeight-pdfPageMargin {
+		t.pdf.AddPage()
+		t.header()
+	}
+
+	for i, cell := range cells {
+		text := pdfText(cell)
+		// Long names are cut off to keep one row per transaction.
+		if t.pdf.GetStringWidth(text) > t.widths[i]-2 {
+			for len(text) > 0 && t.pdf.GetStringWidth(text+"...") > t.widths[i]-2 {
+				text = text[:len(text)-1]
+			}
+			text = strings.TrimRight(text, " ") + "..."
+		}
+
+		t.pdf.CellFormat(t.widths[i], pdfRowHeight, text, "B", 0, t.aligns[i], false, 0, "")
+	}
+	t.pdf.Ln(-1)
+}
+
+func pdfHeading(pdf *gofpdf.Fpdf, text string) {
+	pdf.Ln(4)
+	pdf.SetFont(pdfFont, "B", 13)
+	pdf.CellFormat(0, 9, pdfText(text), "", 1, "L", false, 0, "")
+}
+
+func pdfTransactions(pdf *gofpdf.Fpdf, transactions []*models.Transaction) {
+	if len(transactions) == 0 {
+		pdf.SetFont(pdfFont, "", 9)
+		pdf.CellFormat(0, pdfRowHeight, "No transactions.", "", 1, "L", false, 0, "")
+		return
+	}
+
+	table := &pdfTable{
+		pdf:     pdf,
+		headers: []string{"Date", "Name", "Tag", "Amount"},
+		widths:  []float64{25, 90, 40, 25},
+		aligns:  []string{"L", "L", "L", "R"},
+	}
+	table.header()
+	for _, transaction := range transactions {
+		table.row(
+			transaction.CreatedAt.Format("2. 1. 2006"),
+			transaction.Name,
+			tagName(transaction),
+			models.FormatCurrency(transaction.Value),
+		)
+	}
+}
+
+// writePdf writes the report as an A4 pdf document with the same sections as the html report.
+func writePdf(w io.Writer, report *report) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfPageMargin, pdfPageMargin, pdfPageMargin)
+	pdf.SetAutoPageBreak(false, pdfPageMargin)
+	pdf.SetTitle(report.wallet.Name+": "+report.form.title(), true)
+	pdf.SetCreator("Šparovec", true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(107, 114, 128)
+		pdf.CellFormat(0, 5, "Page "+strconv.Itoa(pdf.PageNo())+" of {nb}", "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	pdf.SetDrawColor(209, 213, 219)
+	pdf.AddPage()
+
+	pdf.SetFont(pdfFont, "B", 18)
+	pdf.CellFormat(0, 10, pdfText(report.wallet.Name+": "+report.form.title()), "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", 9)
+	pdf.SetTextColor(107, 114, 128)
+	pdf.CellFormat(0, 5, pdfText(
+		report.form.dateRange.From.Format("2. 1. 2006")+" – "+report.form.dateRange.To.Format("2. 1. 2006")+
+			", generated on "+report.generatedAt.Format("2. 1. 2006 15:04"),
+	), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	pdfHeading(pdf, "Summary")
+	summary := &pdfTable{
+		pdf:    pdf,
+		widths: []float64{60, 30},
+		aligns: []string{"L", "R"},
+	}
+	summary.row("Opening balance", models.FormatCurrency(report.openingBalance))
+	summary.row("Money in", models.FormatCurrency(report.income))
+	summary.row("Expenses", models.FormatCurrency(report.outcome))
+	summary.row("Net", models.FormatCurrency(report.income+report.outcome))
+	summary.row("Closing balance", models.FormatCurrency(report.closingBalance()))
+	summary.row("Nr. of transactions", strconv.Itoa(len(report.transactions)))
+
+	pdfHeading(pdf, "By Tag")
+	tags := &pdfTable{
+		pdf:     pdf,
+		headers: []string{"Tag", "Transactions", "Money in", "Expenses", "Share of expenses"},
+		widths:  []float64{55, 25, 30, 30, 40},
+		aligns:  []string{"L", "R", "R", "R", "R"},
+	}
+	tags.header()
+	for _, tag := range report.tags {
+		tags.row(
+			tag.tag.Name,
+			strconv.Itoa(tag.nrTransactions),
+			models.FormatCurrency(tag.income),
+			models.FormatCurrency(tag.outcome),
+			formatShare(report.expenseShare(tag)),
+		)
+	}
+
+	pdfHeading(pdf, "Largest Expenses")
+	pdfTransactions(pdf, report.topExpenses)
+
+	pdfHeading(pdf, "All Transactions")
+	pdfTransactions(pdf, report.transactions)
+
+	return pdf.Output(w)
+}
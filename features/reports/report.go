@@ -0,0 +1,232 @@
+package reports
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+// topExpensesCount is how many of the largest expenses the report lists.
+const topExpensesCount = 10
+
+type period string
+
+const (
+	periodMonth period = "month"
+	periodYear  period = "year"
+	// periodCustom is a range with from and to chosen by the user.
+	periodCustom period = "custom"
+)
+
+// reportForm is the period a report covers.
+type reportForm struct {
+	period    period
+	year      int
+	month     time.Month
+	dateRange models.DateRange
+}
+
+// reportFormFromRequest reads the period from a year and a month, a year, or from and to
+// dates. Months follow the periods of the wallet. By default, the report covers last month.
+func reportFormFromRequest(r *http.Request, wallet *models.Wallet) reportForm {
+	now := time.Now()
+
+	from, fromErr := time.Parse(time.DateOnly, r.FormValue("from"))
+	to, toErr := time.Parse(time.DateOnly, r.FormValue("to"))
+	if fromErr == nil && toErr == nil {
+		if to.Before(from) {
+			from, to = to, from
+		}
+
+		return reportForm{
+			period:    periodCustom,
+			year:      from.Year(),
+			month:     from.Month(),
+			dateRange: models.DateRange{From: from, To: to},
+		}
+	}
+
+	year, err := strconv.Atoi(r.FormValue("year"))
+	if err != nil || year < 1 {
+		year = now.Year()
+	}
+
+	if period(r.FormValue("period")) == periodYear {
+		return reportForm{
+			period: periodYear,
+			year:   year,
+			month:  time.January,
+			dateRange: models.DateRange{
+				From: time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC),
+			},
+		}
+	}
+
+	month, err := strconv.Atoi(r.FormValue("month"))
+	if err != nil || month < 1 || month > 12 {
+		lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+		year, month = lastMonth.Year(), int(lastMonth.Month())
+	}
+
+	return reportForm{
+		period:    periodMonth,
+		year:      year,
+		month:     time.Month(month),
+		dateRange: wallet.Period(time.Date(year, time.Month(month), 28, 0, 0, 0, 0, time.UTC)),
+	}
+}
+
+// query returns the query string of the report, for links to its html and pdf versions.
+func (f reportForm) query() string {
+	values := url.Values{}
+	switch f.period {
+	case periodYear:
+		values.Set("period", string(periodYear))
+		values.Set("year", strconv.Itoa(f.year))
+	case periodMonth:
+		values.Set("period", string(periodMonth))
+		values.Set("year", strconv.Itoa(f.year))
+		values.Set("month", strconv.Itoa(int(f.month)))
+	default:
+		values.Set("from", f.dateRange.From.Format(time.DateOnly))
+		values.Set("to", f.dateRange.To.Format(time.DateOnly))
+	}
+
+	return values.Encode()
+}
+
+// title describes the period, like "October 2026" or "2026".
+func (f reportForm) title() string {
+	switch f.period {
+	case periodYear:
+		return strconv.Itoa(f.year)
+	case periodMonth:
+		if f.dateRange.From.Day() == 1 {
+			return f.dateRange.From.Format("January 2006")
+		}
+		fallthrough
+	default:
+		return f.dateRange.From.Format("2. 1. 2006") + " – " + f.dateRange.To.Format("2. 1. 2006")
+	}
+}
+
+// tagTotals are the sums of the transactions with a tag in the report.
+type tagTotals struct {
+	tag            *models.Tag
+	income         int
+	outcome        int
+	nrTransactions int
+}
+
+type report struct {
+	wallet      *models.Wallet
+	form        reportForm
+	generatedAt time.Time
+
+	openingBalance int
+	income         int
+	outcome        int
+	tags           []*tagTotals
+	// Largest expenses first.
+	topExpenses  []*models.Transaction
+	transactions []*models.Transaction
+}
+
+// fileName returns the name of the downloaded report, like sparovec-household-2026-10.pdf.
+func (r *report) fileName() string {
+	name := strings.Map(func(r rune) rune {
+		if r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, r.wallet.Name)
+
+	period := r.form.dateRange.From.Format("2006-01-02") + "-" + r.form.dateRange.To.Format("2006-01-02")
+	switch r.form.period {
+	case periodYear:
+		period = strconv.Itoa(r.form.year)
+	case periodMonth:
+		period = fmt.Sprintf("%d-%02d", r.form.year, r.form.month)
+	}
+
+	return fmt.Sprintf("sparovec-%s-%s.pdf", name, period)
+}
+
+func (r *report) closingBalance() int {
+	return r.openingBalance + r.income + r.outcome
+}
+
+// expenseShare returns the share of all expenses of the report that the tag has, in percent.
+func (r *report) expenseShare(tag *tagTotals) float64 {
+	if r.outcome == 0 {
+		return 0
+	}
+	return float64(tag.outcome) / float64(r.outcome) * 100
+}
+
+// createReport sums the transactions, which have their tags expanded.
+func createReport(
+	wallet *models.Wallet,
+	form reportForm,
+	openingBalance int,
+	transactions []*models.Transaction,
+) *report {
+	rep := &report{
+		wallet:         wallet,
+		form:           form,
+		generatedAt:    time.Now(),
+		openingBalance: openingBalance,
+		transactions:   transactions,
+	}
+
+	byTag := make(map[int]*tagTotals)
+	for _, transaction := range transactions {
+		tag := transaction.Tag
+		if tag == nil {
+			tag = models.OtherTag(wallet.Id)
+		}
+
+		totals, ok := byTag[tag.Id]
+		if !ok {
+			totals = &tagTotals{tag: tag}
+			byTag[tag.Id] = totals
+			rep.tags = append(rep.tags, totals)
+		}
+
+		totals.nrTransactions++
+		// Transactions with a zero value are neither income nor expenses.
+		switch {
+		case transaction.Value > 0:
+			rep.income += transaction.Value
+			totals.income += transaction.Value
+		case transaction.Value < 0:
+			rep.outcome += transaction.Value
+			totals.outcome += transaction.Value
+			rep.topExpenses = append(rep.topExpenses, transaction)
+		}
+	}
+
+	sort.Slice(rep.tags, func(i, j int) bool {
+		if rep.tags[i].outcome == rep.tags[j].outcome {
+			return rep.tags[i].tag.Name < rep.tags[j].tag.Name
+		}
+		return rep.tags[i].outcome < rep.tags[j].outcome
+	})
+
+	sort.SliceStable(rep.topExpenses, func(i, j int) bool {
+		return rep.topExpenses[i].Value < rep.topExpenses[j].Value
+	})
+	if len(rep.topExpenses) > topExpensesCount {
+		rep.topExpenses = rep.topExpenses[:topExpensesCount]
+	}
+
+	return rep
+}
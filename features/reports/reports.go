@@ -0,0 +1,202 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/viddrobnic/sparovec/features"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+type Repository interface {
+	Transactions(ctx context.Context, walletId int, dateRange models.DateRange) ([]*models.Transaction, error)
+	BalanceBefore(ctx context.Context, walletId int, day time.Time) (int, error)
+}
+
+type WalletRepository interface {
+	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
+	ForId(ctx context.Context, walletId int) (*models.Wallet, error)
+	Role(ctx context.Context, walletId, userId int) (models.Role, error)
+}
+
+type TransactionsService interface {
+	ExpandTags(ctx context.Context, transactions []*models.Transaction) error
+}
+
+type Reports struct {
+	repository          Repository
+	walletRepository    WalletRepository
+	transactionsService TransactionsService
+
+	log *slog.Logger
+}
+
+func New(
+	repository Repository,
+	walletRepository WalletRepository,
+	transactionsService TransactionsService,
+	log *slog.Logger,
+) *Reports {
+	return &Reports{
+		repository:          repository,
+		walletRepository:    walletRepository,
+		transactionsService: transactionsService,
+
+		log: log,
+	}
+}
+
+func (rep *Reports) Mount(router chi.Router) {
+	group := chi.NewRouter()
+	group.Use(auth.RequiredMiddleware)
+
+	group.Get("/", rep.reports)
+	group.Get("/view", rep.view)
+	group.Get("/pdf", rep.pdf)
+
+	router.Mount("/wallets/{walletId}/reports", group)
+}
+
+// wallet returns the wallet if the user can view it, or writes an error.
+func (rep *Reports) wallet(w http.ResponseWriter, r *http.Request) *models.Wallet {
+	ctx := r.Context()
+	walletId := features.GetWalletId(r)
+	user := auth.GetUser(r)
+
+	role, err := rep.walletRepository.Role(ctx, walletId, user.Id)
+	if err != nil {
+		rep.log.ErrorContext(ctx, "Failed to get wallet role", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+
+	if !role.Includes(models.RoleViewer) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil
+	}
+
+	wallet, err := rep.walletRepository.ForId(ctx, walletId)
+	if err != nil {
+		rep.log.ErrorContext(ctx, "Failed to get wallet", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	if wallet == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return nil
+	}
+
+	return wallet
+}
+
+// reports shows the form for choosing the period of a report.
+func (rep *Reports) reports(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	wallet := rep.wallet(w, r)
+	if wallet == nil {
+		return
+	}
+
+	wallets, err := rep.walletRepository.ForUser(ctx, user.Id)
+	if err != nil {
+		rep.log.ErrorContext(ctx, "Failed to get user wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := reportsViewData{
+		navbar: models.Navbar{
+			SelectedWalletId: wallet.Id,
+			Wallets:          wallets,
+			Username:         user.Username,
+			IsAdmin:          user.IsAdmin,
+			Title:            "Šparovec | Reports",
+		},
+		form: reportFormFromRequest(r, wallet),
+	}
+	view := reportsView(data)
+	err = view.Render(ctx, w)
+	if err != nil {
+		rep.log.ErrorContext(ctx, "Error rendering reports view", "error", err)
+	}
+}
+
+func (rep *Reports) report(w http.ResponseWriter, r *http.Request) *report {
+	ctx := r.Context()
+
+	wallet := rep.wallet(w, r)
+	if wallet == nil {
+		return nil
+	}
+
+	form := reportFormFromRequest(r, wallet)
+
+	openingBalance, err := rep.repository.BalanceBefore(ctx, wallet.Id, form.dateRange.From)
+	if err != nil {
+		rep.log.ErrorContext(ctx, "Failed to get opening balance", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+
+	transactions, err := rep.repository.Transactions(ctx, wallet.Id, form.dateRange)
+	if err != nil {
+		rep.log.ErrorContext(ctx, "Failed to get transactions", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+
+	err = rep.transactionsService.ExpandTags(ctx, transactions)
+	if err != nil {
+		rep.log.ErrorContext(ctx, "Failed to expand tags", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+
+	return createReport(wallet, form, openingBalance, transactions)
+}
+
+// view renders the report as a standalone html page for printing.
+func (rep *Reports) view(w http.ResponseWriter, r *http.Request) {
+	report := rep.report(w, r)
+	if report == nil {
+		return
+	}
+
+	view := reportView(report)
+	err := view.Render(r.Context(), w)
+	if err != nil {
+		rep.log.ErrorContext(r.Context(), "Error rendering report", "error", err)
+	}
+}
+
+// pdf downloads the report as a pdf file.
+func (rep *Reports) pdf(w http.ResponseWriter, r *http.Request) {
+	report := rep.report(w, r)
+	if report == nil {
+		return
+	}
+
+	// The pdf is written to a buffer first, so that errors can still be reported.
+	var buf bytes.Buffer
+	err := writePdf(&buf, report)
+	if err != nil {
+		rep.log.ErrorContext(r.Context(), "Failed to write pdf report", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.fileName()))
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		rep.log.ErrorContext(r.Context(), "Failed to send pdf report", "error", err)
+	}
+}
@@ -0,0 +1,71 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/database"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+type RepositoryImpl struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *RepositoryImpl {
+	return &RepositoryImpl{db: db}
+}
+
+// Transactions returns the transactions of the wallet made on the days in the range, oldest first.
+// Tags of the transactions only have the id set.
+func (r *RepositoryImpl) Transactions(ctx context.Context, walletId int, dateRange models.DateRange) ([]*models.Transaction, error) {
+	builder := sq.Select("*").
+		From("transactions").
+		Where(sq.Eq{
+			"wallet_id":  walletId,
+			"deleted_at": nil,
+		}).
+		OrderBy("created_at", "id")
+
+	builder = database.InRange(builder, "created_at", dateRange)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	dbTransactions := []*models.DbTransaction{}
+	err = r.db.SelectContext(ctx, &dbTransactions, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*models.Transaction, len(dbTransactions))
+	for i, dbTransaction := range dbTransactions {
+		transactions[i] = dbTransaction.ToModel()
+	}
+
+	return transactions, nil
+}
+
+// BalanceBefore returns the sum of the transactions of the wallet made before the day.
+func (r *RepositoryImpl) BalanceBefore(ctx context.Context, walletId int, day time.Time) (int, error) {
+	builder := sq.Select("COALESCE(SUM(value), 0)").
+		From("transactions").
+		Where(sq.Eq{
+			"wallet_id":  walletId,
+			"deleted_at": nil,
+		}).
+		Where("created_at < ?", day.Format(time.DateOnly))
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var balance int
+	err = r.db.GetContext(ctx, &balance, stmt, args...)
+	return balance, err
+}
@@ -0,0 +1,69 @@
+package tags
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/viddrobnic/sparovec/features/icons"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const maxDescriptionLength = 200
+
+var colorRegex = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+type saveTagForm struct {
+	Id          int    `form:"id"`
+	Name        string `form:"name"`
+	Color       string `form:"color"`
+	Icon        string `form:"icon"`
+	Description string `form:"description"`
+}
+
+func (f *saveTagForm) parse(walletId int) (*models.Tag, error) {
+	name := strings.TrimSpace(f.Name)
+	if name == "" {
+		return nil, &models.ErrInvalidForm{Message: "Name must not be empty"}
+	}
+
+	color := strings.ToLower(f.Color)
+	if color == "" {
+		color = models.DefaultTagColor
+	}
+	if !colorRegex.MatchString(color) {
+		return nil, &models.ErrInvalidForm{Message: "Invalid color"}
+	}
+
+	if !icons.IsValid(f.Icon) {
+		return nil, &models.ErrInvalidForm{Message: "Invalid icon"}
+	}
+
+	description := strings.TrimSpace(f.Description)
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return nil, &models.ErrInvalidForm{Message: "Description is too long"}
+	}
+
+	return &models.Tag{
+		Id:          f.Id,
+		WalletId:    walletId,
+		Name:        name,
+		Color:       color,
+		Icon:        f.Icon,
+		Description: description,
+	}, nil
+}
+
+func saveTagFormFromRequest(r *http.Request) *saveTagForm {
+	id, _ := strconv.Atoi(r.FormValue("id"))
+
+	return &saveTagForm{
+		Id:          id,
+		Name:        r.FormValue("name"),
+		Color:       r.FormValue("color"),
+		Icon:        r.FormValue("icon"),
+		Description: r.FormValue("description"),
+	}
+}
@@ -2,9 +2,13 @@ package tags
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/features/audit"
 	"github.com/viddrobnic/sparovec/models"
 )
 
@@ -20,6 +24,7 @@ func (t *RepositoryImpl) List(ctx context.Context, walletId int) ([]*models.Tag,
 	builder := sq.Select("*").
 		From("tags").
 		Where("wallet_id = ?", walletId).
+		Where("deleted_at IS NULL").
 		OrderBy("name", "id")
 
 	stmt, args, err := builder.ToSql()
@@ -32,10 +37,23 @@ func (t *RepositoryImpl) List(ctx context.Context, walletId int) ([]*models.Tag,
 	return tags, err
 }
 
-func (t *RepositoryImpl) Create(ctx context.Context, walletId int, name string) (*models.Tag, error) {
+func (t *RepositoryImpl) Create(ctx context.Context, tag *models.Tag) (*models.Tag, error) {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = checkName(ctx, tx, tag.WalletId, 0, tag.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	builder := sq.Insert("tags").
-		Columns("wallet_id", "name").
-		Values(walletId, name).
+		Columns("wallet_id", "name", "color", "icon", "description").
+		Values(tag.WalletId, tag.Name, tag.Color, tag.Icon, tag.Description).
 		Suffix("RETURNING *")
 
 	stmt, args, err := builder.ToSql()
@@ -43,15 +61,26 @@ func (t *RepositoryImpl) Create(ctx context.Context, walletId int, name string)
 		return nil, err
 	}
 
-	tag := &models.Tag{}
-	err = t.db.GetContext(ctx, tag, stmt, args...)
-	return tag, err
+	created := &models.Tag{}
+	err = tx.GetContext(ctx, created, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = audit.RecordChange(ctx, tx, created.WalletId, models.AuditTag, created.Id, models.AuditCreate, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, tx.Commit()
 }
 
+// Get returns the tag, or nil if it doesn't exist or is in the trash.
 func (t *RepositoryImpl) Get(ctx context.Context, tagId int) (*models.Tag, error) {
 	builder := sq.Select("*").
 		From("tags").
-		Where("id = ?", tagId)
+		Where("id = ?", tagId).
+		Where("deleted_at IS NULL")
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
@@ -60,13 +89,38 @@ func (t *RepositoryImpl) Get(ctx context.Context, tagId int) (*models.Tag, error
 
 	tag := &models.Tag{}
 	err = t.db.GetContext(ctx, tag, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
 	return tag, err
 }
 
-func (t *RepositoryImpl) Update(ctx context.Context, tagId int, name string) (*models.Tag, error) {
+func (t *RepositoryImpl) Update(ctx context.Context, tag *models.Tag) (*models.Tag, error) {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = checkName(ctx, tx, tag.WalletId, tag.Id, tag.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := audit.TagFields(ctx, tx, tag.WalletId, tag.Id)
+	if err != nil {
+		return nil, err
+	}
+
 	builder := sq.Update("tags").
-		Set("name", name).
-		Where("id = ?", tagId).
+		Set("name", tag.Name).
+		Set("color", tag.Color).
+		Set("icon", tag.Icon).
+		Set("description", tag.Description).
+		Where(sq.Eq{"id": tag.Id, "wallet_id": tag.WalletId, "deleted_at": nil}).
 		Suffix("RETURNING *")
 
 	stmt, args, err := builder.ToSql()
@@ -74,21 +128,322 @@ func (t *RepositoryImpl) Update(ctx context.Context, tagId int, name string) (*m
 		return nil, err
 	}
 
+	updated := &models.Tag{}
+	err = tx.GetContext(ctx, updated, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = audit.RecordChange(ctx, tx, tag.WalletId, models.AuditTag, tag.Id, models.AuditUpdate, before)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, tx.Commit()
+}
+
+// checkName returns models.ErrTagExists if another tag in the wallet has the name,
+// and models.ErrInvalidForm if the tag with the name is in the trash.
+func checkName(ctx context.Context, tx *sqlx.Tx, walletId, tagId int, name string) error {
+	builder := sq.Select("*").
+		From("tags").
+		Where(sq.Eq{"wallet_id": walletId, "name": name}).
+		Where(sq.NotEq{"id": tagId})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	existing := &models.Tag{}
+	err = tx.GetContext(ctx, existing, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if existing.DeletedAt.Valid {
+		return &models.ErrInvalidForm{
+			Message: fmt.Sprintf("Tag %s is in the trash. Restore it from the trash instead.", name),
+		}
+	}
+
+	return &models.ErrTagExists{Tag: existing}
+}
+
+// Delete moves the tag to the trash. If moveToTagId is set, its transactions
+// are first moved to that tag. Otherwise they are kept, but are shown without
+// the tag until it is restored.
+func (t *RepositoryImpl) Delete(ctx context.Context, walletId, tagId, moveToTagId int) error {
+	if moveToTagId == 0 {
+		return t.setDeleted(ctx, walletId, tagId, true)
+	}
+
+	return t.moveAndDelete(ctx, walletId, tagId, moveToTagId, func(tx *sqlx.Tx) error {
+		builder := sq.Update("tags").
+			Set("deleted_at", sq.Expr("datetime('now')")).
+			Where(sq.Eq{"id": tagId, "wallet_id": walletId})
+
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
+		return err
+	})
+}
+
+// Merge moves all transactions of the source tag to the target tag
+// and permanently deletes the source tag.
+func (t *RepositoryImpl) Merge(ctx context.Context, walletId, sourceTagId, targetTagId int) error {
+	return t.moveAndDelete(ctx, walletId, sourceTagId, targetTagId, func(tx *sqlx.Tx) error {
+		builder := sq.Delete("tags").Where(sq.Eq{"id": sourceTagId, "wallet_id": walletId})
+
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
+		return err
+	})
+}
+
+// moveAndDelete moves the transactions of the tag to the target tag and deletes
+// the tag with the given function, all in one database transaction.
+func (t *RepositoryImpl) moveAndDelete(ctx context.Context, walletId, tagId, targetTagId int, delete func(tx *sqlx.Tx) error) error {
+	if tagId == targetTagId {
+		return &models.ErrInvalidForm{Message: "Tag can't be merged into itself"}
+	}
+
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	target, err := activeTag(ctx, tx, walletId, targetTagId)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return &models.ErrInvalidForm{Message: "Invalid tag"}
+	}
+
+	before, err := audit.TagFields(ctx, tx, walletId, tagId)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return &models.ErrInvalidForm{Message: "Invalid tag"}
+	}
+
+	// Transactions in the trash are moved as well, so that they keep their tag when restored.
+	idsBuilder := sq.Select("id").
+		From("transactions").
+		Where(sq.Eq{"wallet_id": walletId, "tag_id": tagId})
+
+	stmt, args, err := idsBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	transactionIds := []int{}
+	err = tx.SelectContext(ctx, &transactionIds, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	transactionsBefore := make([][]models.AuditField, len(transactionIds))
+	for i, id := range transactionIds {
+		transactionsBefore[i], err = audit.TransactionFields(ctx, tx, walletId, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	moveBuilder := sq.Update("transactions").
+		Set("tag_id", targetTagId).
+		Where(sq.Eq{"wallet_id": walletId, "tag_id": tagId})
+
+	stmt, args, err = moveBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range transactionIds {
+		err = audit.RecordChange(ctx, tx, walletId, models.AuditTransaction, id, models.AuditUpdate, transactionsBefore[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = delete(tx)
+	if err != nil {
+		return err
+	}
+
+	err = audit.RecordChange(ctx, tx, walletId, models.AuditTag, tagId, models.AuditDelete, before)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func activeTag(ctx context.Context, tx *sqlx.Tx, walletId, tagId int) (*models.Tag, error) {
+	builder := sq.Select("*").
+		From("tags").
+		Where(sq.Eq{"id": tagId, "wallet_id": walletId, "deleted_at": nil})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
 	tag := &models.Tag{}
-	err = t.db.GetContext(ctx, tag, stmt, args...)
+	err = tx.GetContext(ctx, tag, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
 	return tag, err
 }
 
-func (t *RepositoryImpl) Delete(ctx context.Context, tagId int) error {
-	builder := sq.Delete("tags").Where("id = ?", tagId)
+// Restore restores the tag from the trash.
+func (t *RepositoryImpl) Restore(ctx context.Context, walletId, tagId int) error {
+	return t.setDeleted(ctx, walletId, tagId, false)
+}
+
+func (t *RepositoryImpl) setDeleted(ctx context.Context, walletId, tagId int, deleted bool) error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := sq.Update("tags").Where(sq.Eq{"id": tagId, "wallet_id": walletId})
+	action := models.AuditRestore
+	if deleted {
+		builder = builder.Set("deleted_at", sq.Expr("datetime('now')")).Where("deleted_at IS NULL")
+		action = models.AuditDelete
+	} else {
+		builder = builder.Set("deleted_at", nil).Where("deleted_at IS NOT NULL")
+	}
+
+	before, err := audit.TagFields(ctx, tx, walletId, tagId)
+	if err != nil {
+		return err
+	}
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = t.db.ExecContext(ctx, stmt, args...)
-	return err
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	changed, err := res.RowsAffected()
+	if err != nil || changed == 0 {
+		return err
+	}
+
+	if action == models.AuditRestore {
+		before = nil
+	}
+
+	err = audit.RecordChange(ctx, tx, walletId, models.AuditTag, tagId, action, before)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Deleted returns the tags in the trash of the wallet, most recently deleted first.
+func (t *RepositoryImpl) Deleted(ctx context.Context, walletId int) ([]*models.Tag, error) {
+	builder := sq.Select("*").
+		From("tags").
+		Where("wallet_id = ?", walletId).
+		Where("deleted_at IS NOT NULL").
+		OrderBy("deleted_at DESC", "id DESC")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []*models.Tag{}
+	err = t.db.SelectContext(ctx, &tags, stmt, args...)
+	return tags, err
+}
+
+// Purge permanently deletes tags that have been in the trash for longer than the retention.
+// Their transactions are kept without a tag.
+func (t *RepositoryImpl) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	expired := sq.Select("id").
+		From("tags").
+		Where("deleted_at < datetime('now', ?)", fmt.Sprintf("-%d seconds", int(retention.Seconds())))
+	expiredSql, expiredArgs, err := expired.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	// Transactions would be deleted together with the tag by the foreign key.
+	untagBuilder := sq.Update("transactions").
+		Set("tag_id", nil).
+		Where("tag_id IN ("+expiredSql+")", expiredArgs...)
+
+	stmt, args, err := untagBuilder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	builder := sq.Delete("tags").Where("id IN ("+expiredSql+")", expiredArgs...)
+
+	stmt, args, err = builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(purged), tx.Commit()
 }
 
 func (t *RepositoryImpl) GetIds(ctx context.Context, ids []int) ([]*models.Tag, error) {
@@ -98,7 +453,8 @@ func (t *RepositoryImpl) GetIds(ctx context.Context, ids []int) ([]*models.Tag,
 
 	builder := sq.Select("*").
 		From("tags").
-		Where(sq.Eq{"id": ids})
+		Where(sq.Eq{"id": ids}).
+		Where("deleted_at IS NULL")
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
@@ -2,6 +2,9 @@ package tags
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -15,15 +18,16 @@ import (
 
 type WalletRepository interface {
 	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
-	HasPermission(ctx context.Context, walletId, userId int) (bool, error)
+	Role(ctx context.Context, walletId, userId int) (models.Role, error)
 }
 
 type Repository interface {
 	List(ctx context.Context, walletId int) ([]*models.Tag, error)
-	Create(ctx context.Context, walletId int, name string) (*models.Tag, error)
+	Create(ctx context.Context, tag *models.Tag) (*models.Tag, error)
 	Get(ctx context.Context, tagId int) (*models.Tag, error)
-	Update(ctx context.Context, tagId int, name string) (*models.Tag, error)
-	Delete(ctx context.Context, tagId int) error
+	Update(ctx context.Context, tag *models.Tag) (*models.Tag, error)
+	Delete(ctx context.Context, walletId, tagId, moveToTagId int) error
+	Merge(ctx context.Context, walletId, sourceTagId, targetTagId int) error
 }
 
 type Tags struct {
@@ -54,38 +58,32 @@ func (t *Tags) Mount(router chi.Router) {
 	group.Post("/", t.createTag)
 	group.Put("/", t.updateTag)
 	group.Post("/delete", t.deleteTag)
+	group.Post("/merge", t.mergeTag)
 
 	router.Mount("/wallets/{walletId}/tags", group)
 }
 
-func (t *Tags) listTags(ctx context.Context, walletId int, user *models.User) ([]*models.Tag, error) {
-	hasPermission, err := t.walletRepository.HasPermission(ctx, walletId, user.Id)
+// memberRole returns the role of the user in the wallet. If the user
+// doesn't have at least the required role, an error is written and false is returned.
+func (t *Tags) memberRole(ctx context.Context, w http.ResponseWriter, walletId, userId int, required models.Role) (models.Role, bool) {
+	role, err := t.walletRepository.Role(ctx, walletId, userId)
 	if err != nil {
-		t.log.ErrorContext(ctx, "Failed to get wallet permission", "error", err)
-		return nil, models.ErrInternalServer
-	}
-
-	if !hasPermission {
-		return []*models.Tag{}, nil
-	}
-
-	return t.repository.List(ctx, walletId)
-}
-
-func (t *Tags) hasPermission(ctx context.Context, w http.ResponseWriter, walletId, userId int) bool {
-	hasPermission, err := t.walletRepository.HasPermission(ctx, walletId, userId)
-	if err != nil {
-		t.log.ErrorContext(ctx, "Failed to get wallet permission", "error", err)
+		t.log.ErrorContext(ctx, "Failed to get wallet role", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return false
+		return "", false
 	}
 
-	if !hasPermission {
+	if !role.Includes(required) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
-		return false
+		return "", false
 	}
 
-	return true
+	return role, true
+}
+
+func (t *Tags) hasPermission(ctx context.Context, w http.ResponseWriter, walletId, userId int, required models.Role) bool {
+	_, ok := t.memberRole(ctx, w, walletId, userId, required)
+	return ok
 }
 
 func (t *Tags) tags(w http.ResponseWriter, r *http.Request) {
@@ -93,6 +91,11 @@ func (t *Tags) tags(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUser(r)
 	walletId := features.GetWalletId(r)
 
+	role, ok := t.memberRole(ctx, w, walletId, user.Id, models.RoleViewer)
+	if !ok {
+		return
+	}
+
 	wallets, err := t.walletRepository.ForUser(ctx, user.Id)
 	if err != nil {
 		t.log.Error("Failed to get wallets", "error", err)
@@ -100,7 +103,7 @@ func (t *Tags) tags(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tags, err := t.listTags(ctx, walletId, user)
+	tags, err := t.repository.List(ctx, walletId)
 	if err != nil {
 		t.log.Error("Failed to get tags", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -111,10 +114,11 @@ func (t *Tags) tags(w http.ResponseWriter, r *http.Request) {
 		SelectedWalletId: walletId,
 		Wallets:          wallets,
 		Username:         user.Username,
+		IsAdmin:          user.IsAdmin,
 		Title:            "Šparovec | Tags",
 	}
 
-	view := tagsView(tags, navbar)
+	view := tagsView(tags, navbar, role.Includes(models.RoleEditor))
 	err = view.Render(ctx, w)
 	if err != nil {
 		t.log.Error("Failed to render view", "error", err)
@@ -126,15 +130,25 @@ func (t *Tags) createTag(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUser(r)
 	walletId := features.GetWalletId(r)
 
-	if hasPermission := t.hasPermission(ctx, w, walletId, user.Id); !hasPermission {
+	if hasPermission := t.hasPermission(ctx, w, walletId, user.Id, models.RoleEditor); !hasPermission {
 		return
 	}
 
-	name := r.FormValue("name")
-	_, err := t.repository.Create(ctx, walletId, name)
+	tag, err := saveTagFormFromRequest(r).parse(walletId)
 	if err != nil {
-		t.log.Error("Failed to create tag", "error", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		t.handleError(w, err, "Failed to parse tag")
+		return
+	}
+
+	_, err = t.repository.Create(ctx, tag)
+
+	var tagExists *models.ErrTagExists
+	if errors.As(err, &tagExists) {
+		err = &models.ErrInvalidForm{Message: fmt.Sprintf("Tag %s already exists.", tag.Name)}
+	}
+
+	if err != nil {
+		t.handleError(w, err, "Failed to create tag")
 		return
 	}
 
@@ -147,23 +161,29 @@ func (t *Tags) updateTag(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUser(r)
 	walletId := features.GetWalletId(r)
 
-	if hasPermission := t.hasPermission(ctx, w, walletId, user.Id); !hasPermission {
+	if hasPermission := t.hasPermission(ctx, w, walletId, user.Id, models.RoleEditor); !hasPermission {
 		return
 	}
 
-	idStr := r.FormValue("id")
-	id, err := strconv.Atoi(idStr)
+	tag, err := saveTagFormFromRequest(r).parse(walletId)
 	if err != nil {
-		t.log.Error("Failed to parse tag id", "error", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		t.handleError(w, err, "Failed to parse tag")
+		return
+	}
+
+	_, err = t.repository.Update(ctx, tag)
+
+	// Offer to merge the tag into the existing one instead.
+	var tagExists *models.ErrTagExists
+	if errors.As(err, &tagExists) {
+		t.triggerEvent(w, map[string]tagExistsEvent{
+			eventTagExists: {SourceId: tag.Id, TargetId: tagExists.Tag.Id, TargetName: tagExists.Tag.Name},
+		})
 		return
 	}
-	name := r.FormValue("name")
 
-	_, err = t.repository.Update(ctx, id, name)
 	if err != nil {
-		t.log.Error("Failed to update tag", "error", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		t.handleError(w, err, "Failed to update tag")
 		return
 	}
 
@@ -176,7 +196,7 @@ func (t *Tags) deleteTag(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUser(r)
 	walletId := features.GetWalletId(r)
 
-	if hasPermission := t.hasPermission(ctx, w, walletId, user.Id); !hasPermission {
+	if hasPermission := t.hasPermission(ctx, w, walletId, user.Id, models.RoleEditor); !hasPermission {
 		return
 	}
 
@@ -188,13 +208,100 @@ func (t *Tags) deleteTag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = t.repository.Delete(ctx, id)
+	// Empty if the transactions are left without a tag.
+	var moveToId int
+	if moveTo := r.FormValue("move_to"); moveTo != "" {
+		moveToId, err = strconv.Atoi(moveTo)
+		if err != nil {
+			t.handleError(w, &models.ErrInvalidForm{Message: "Invalid tag"}, "")
+			return
+		}
+	}
+
+	err = t.repository.Delete(ctx, walletId, id, moveToId)
+	if err != nil {
+		t.handleError(w, err, "Failed to delete tag")
+		return
+	}
+
+	undoUrl := fmt.Sprintf("/wallets/%d/trash/restore?entity=%s&id=%d", walletId, models.AuditTag, id)
+	events, err := htmx.DeleteWithUndo("Tag moved to trash.", undoUrl)
+	if err != nil {
+		t.log.Error("Failed to marshal delete events", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(htmx.HeaderTriggerAfterSettle, events)
+	t.tags(w, r)
+}
+
+func (t *Tags) mergeTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+	walletId := features.GetWalletId(r)
+
+	if hasPermission := t.hasPermission(ctx, w, walletId, user.Id, models.RoleEditor); !hasPermission {
+		return
+	}
+
+	sourceId, err := strconv.Atoi(r.FormValue("source"))
 	if err != nil {
-		t.log.Error("Failed to delete tag", "error", err)
+		t.log.Error("Failed to parse source tag id", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set(htmx.HeaderTriggerAfterSettle, htmx.EventDeleteSuccess)
+	targetId, err := strconv.Atoi(r.FormValue("target"))
+	if err != nil {
+		t.handleError(w, &models.ErrInvalidForm{Message: "Select a tag to merge into"}, "")
+		return
+	}
+
+	err = t.repository.Merge(ctx, walletId, sourceId, targetId)
+	if err != nil {
+		t.handleError(w, err, "Failed to merge tags")
+		return
+	}
+
+	w.Header().Set(htmx.HeaderTriggerAfterSettle, eventMergeSuccess)
 	t.tags(w, r)
 }
+
+const (
+	eventTagExists    = "tagExists"
+	eventMergeSuccess = "mergeSuccess"
+)
+
+// tagExistsEvent is triggered when a tag is renamed to the name of another tag.
+type tagExistsEvent struct {
+	SourceId   int    `json:"sourceId"`
+	TargetId   int    `json:"targetId"`
+	TargetName string `json:"targetName"`
+}
+
+// handleError shows invalid form errors in the open modal and logs other errors with the message.
+func (t *Tags) handleError(w http.ResponseWriter, err error, message string) {
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		t.triggerEvent(w, htmx.EventSaveError{ErrorMessage: invalidForm.Message})
+		return
+	}
+
+	t.log.Error(message, "error", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+// triggerEvent triggers the event without swapping the page.
+func (t *Tags) triggerEvent(w http.ResponseWriter, event any) {
+	eventJson, err := json.Marshal(event)
+	if err != nil {
+		t.log.Error("Failed to marshal event", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(htmx.HeaderReswap, htmx.SwapNone)
+	w.Header().Set(htmx.HeaderTriggerAfterSettle, string(eventJson))
+	w.WriteHeader(http.StatusOK)
+}
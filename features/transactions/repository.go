@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/features/audit"
 	"github.com/viddrobnic/sparovec/models"
 )
 
@@ -24,6 +26,14 @@ func (t *RepositoryImpl) Create(ctx context.Context, transaction *models.Transac
 		tagId = &transaction.Tag.Id
 	}
 
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := sq.Insert("transactions").
 		Columns(
 			"wallet_id",
@@ -45,7 +55,17 @@ func (t *RepositoryImpl) Create(ctx context.Context, transaction *models.Transac
 	}
 
 	dbTransaction := &models.DbTransaction{}
-	err = t.db.GetContext(ctx, dbTransaction, stmt, args...)
+	err = tx.GetContext(ctx, dbTransaction, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	err = audit.RecordChange(ctx, tx, transaction.WalletId, models.AuditTransaction, dbTransaction.Id, models.AuditCreate, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		return err
 	}
@@ -55,6 +75,18 @@ func (t *RepositoryImpl) Create(ctx context.Context, transaction *models.Transac
 }
 
 func (t *RepositoryImpl) CreateMany(ctx context.Context, transactions []*models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := sq.Insert("transactions").
 		Columns(
 			"wallet_id",
@@ -62,7 +94,8 @@ func (t *RepositoryImpl) CreateMany(ctx context.Context, transactions []*models.
 			"value",
 			"tag_id",
 			"created_at",
-		)
+		).
+		Suffix("RETURNING id, wallet_id")
 
 	for _, tr := range transactions {
 		var tagId *int
@@ -84,12 +117,23 @@ func (t *RepositoryImpl) CreateMany(ctx context.Context, transactions []*models.
 		return fmt.Errorf("query toSql: %w", err)
 	}
 
-	_, err = t.db.ExecContext(ctx, query, args...)
+	created := []struct {
+		Id       int
+		WalletId int `db:"wallet_id"`
+	}{}
+	err = tx.SelectContext(ctx, &created, query, args...)
 	if err != nil {
 		return fmt.Errorf("execute query: %w", err)
 	}
 
-	return nil
+	for _, tr := range created {
+		err = audit.RecordChange(ctx, tx, tr.WalletId, models.AuditTransaction, tr.Id, models.AuditCreate, nil)
+		if err != nil {
+			return fmt.Errorf("record change: %w", err)
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (t *RepositoryImpl) Update(ctx context.Context, transaction *models.Transaction) error {
@@ -101,25 +145,48 @@ func (t *RepositoryImpl) Update(ctx context.Context, transaction *models.Transac
 		}
 	}
 
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	before, err := audit.TransactionFields(ctx, tx, transaction.WalletId, transaction.Id)
+	if err != nil || before == nil {
+		return err
+	}
+
 	builder := sq.Update("transactions").
 		Set("name", transaction.Name).
 		Set("value", transaction.Value).
 		Set("tag_id", tagId).
 		Set("created_at", transaction.CreatedAt).
-		Where("id = ?", transaction.Id)
+		Where(sq.Eq{"id": transaction.Id, "wallet_id": transaction.WalletId, "deleted_at": nil})
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = t.db.ExecContext(ctx, stmt, args...)
-	return err
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	err = audit.RecordChange(ctx, tx, transaction.WalletId, models.AuditTransaction, transaction.Id, models.AuditUpdate, before)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (t *RepositoryImpl) List(ctx context.Context, req *models.TransactionsListRequest) ([]*models.Transaction, int, error) {
 	builder := sq.Select("*").From("transactions").
-		Where("wallet_id = ? ", req.WalletId)
+		Where("wallet_id = ? ", req.WalletId).
+		Where("deleted_at IS NULL")
 
 	countBuilder := sq.Select("COUNT(*)").FromSelect(builder, "transactions")
 
@@ -158,16 +225,110 @@ func (t *RepositoryImpl) List(ctx context.Context, req *models.TransactionsListR
 	return transactions, count, nil
 }
 
-func (t *RepositoryImpl) Delete(ctx context.Context, id int) error {
-	builder := sq.Delete("transactions").Where("id = ?", id)
+// Delete moves the transaction to the trash.
+func (t *RepositoryImpl) Delete(ctx context.Context, walletId, id int) error {
+	return t.setDeleted(ctx, walletId, id, true)
+}
+
+// Restore restores the transaction from the trash.
+func (t *RepositoryImpl) Restore(ctx context.Context, walletId, id int) error {
+	return t.setDeleted(ctx, walletId, id, false)
+}
+
+func (t *RepositoryImpl) setDeleted(ctx context.Context, walletId, id int, deleted bool) error {
+	tx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := sq.Update("transactions").Where(sq.Eq{"id": id, "wallet_id": walletId})
+	action := models.AuditRestore
+	if deleted {
+		builder = builder.Set("deleted_at", sq.Expr("datetime('now')")).Where("deleted_at IS NULL")
+		action = models.AuditDelete
+	} else {
+		builder = builder.Set("deleted_at", nil).Where("deleted_at IS NOT NULL")
+	}
+
+	before, err := audit.TransactionFields(ctx, tx, walletId, id)
+	if err != nil {
+		return err
+	}
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = t.db.ExecContext(ctx, stmt, args...)
-	return err
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	changed, err := res.RowsAffected()
+	if err != nil || changed == 0 {
+		return err
+	}
+
+	if action == models.AuditRestore {
+		before = nil
+	}
+
+	err = audit.RecordChange(ctx, tx, walletId, models.AuditTransaction, id, action, before)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Deleted returns the transactions in the trash of the wallet, most recently deleted first.
+func (t *RepositoryImpl) Deleted(ctx context.Context, walletId int) ([]*models.Transaction, error) {
+	builder := sq.Select("*").
+		From("transactions").
+		Where("wallet_id = ?", walletId).
+		Where("deleted_at IS NOT NULL").
+		OrderBy("deleted_at DESC", "id DESC")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	dbTransactions := []*models.DbTransaction{}
+	err = t.db.SelectContext(ctx, &dbTransactions, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*models.Transaction, len(dbTransactions))
+	for i, dbTransaction := range dbTransactions {
+		transactions[i] = dbTransaction.ToModel()
+	}
+
+	return transactions, nil
+}
+
+// Purge permanently deletes transactions that have been in the trash for longer than the retention.
+func (t *RepositoryImpl) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	builder := sq.Delete("transactions").
+		Where("deleted_at < datetime('now', ?)", fmt.Sprintf("-%d seconds", int(retention.Seconds())))
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := t.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+	return int(purged), err
 }
 
 func (t *RepositoryImpl) TagInfoForNames(ctx context.Context, walletId int, names []string) (map[string]int, error) {
@@ -179,6 +340,7 @@ func (t *RepositoryImpl) TagInfoForNames(ctx context.Context, walletId int, name
 		Where("tr_inner.wallet_id = tr.wallet_id").
 		Where("tr_inner.name = tr.name").
 		Where("tr_inner.created_at > tr.created_at").
+		Where("tr_inner.deleted_at IS NULL").
 		Suffix(")")
 
 	builder := sq.
@@ -189,6 +351,8 @@ func (t *RepositoryImpl) TagInfoForNames(ctx context.Context, walletId int, name
 			"name":      names,
 		}).
 		Where("tag_id is not null").
+		Where("deleted_at IS NULL").
+		Where("tag_id IN (SELECT id FROM tags WHERE deleted_at IS NULL)").
 		Where(innerBuilder).
 		OrderBy("created_at DESC")
 
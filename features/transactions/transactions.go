@@ -26,7 +26,7 @@ type Repository interface {
 	CreateMany(ctx context.Context, transactions []*models.Transaction) error
 	Update(ctx context.Context, transaction *models.Transaction) error
 	List(ctx context.Context, req *models.TransactionsListRequest) ([]*models.Transaction, int, error)
-	Delete(ctx context.Context, id int) error
+	Delete(ctx context.Context, walletId, id int) error
 
 	TagInfoForNames(ctx context.Context, walletId int, names []string) (map[string]int, error)
 }
@@ -39,7 +39,7 @@ type TagsRepository interface {
 
 type WalletRepository interface {
 	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
-	HasPermission(ctx context.Context, walletId, userId int) (bool, error)
+	Role(ctx context.Context, walletId, userId int) (models.Role, error)
 }
 
 type Transactions struct {
@@ -77,20 +77,27 @@ func (t *Transactions) Mount(router chi.Router) {
 	router.Mount("/wallets/{walletId}/transactions", group)
 }
 
-func (t *Transactions) hasPermission(ctx context.Context, w http.ResponseWriter, walletId, userId int) bool {
-	hasPermission, err := t.walletRepository.HasPermission(ctx, walletId, userId)
+// memberRole returns the role of the user in the wallet. If the user
+// doesn't have at least the required role, an error is written and false is returned.
+func (t *Transactions) memberRole(ctx context.Context, w http.ResponseWriter, walletId, userId int, required models.Role) (models.Role, bool) {
+	role, err := t.walletRepository.Role(ctx, walletId, userId)
 	if err != nil {
-		t.log.ErrorContext(ctx, "Failed to get wallet permission", "error", err)
+		t.log.ErrorContext(ctx, "Failed to get wallet role", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return false
+		return "", false
 	}
 
-	if !hasPermission {
+	if !role.Includes(required) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
-		return false
+		return "", false
 	}
 
-	return true
+	return role, true
+}
+
+func (t *Transactions) hasPermission(ctx context.Context, w http.ResponseWriter, walletId, userId int, required models.Role) bool {
+	_, ok := t.memberRole(ctx, w, walletId, userId, required)
+	return ok
 }
 
 func (t *Transactions) transactions(w http.ResponseWriter, r *http.Request) {
@@ -98,7 +105,8 @@ func (t *Transactions) transactions(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUser(r)
 	walletId := features.GetWalletId(r)
 
-	if !t.hasPermission(ctx, w, walletId, user.Id) {
+	role, ok := t.memberRole(ctx, w, walletId, user.Id, models.RoleViewer)
+	if !ok {
 		return
 	}
 
@@ -155,6 +163,7 @@ func (t *Transactions) transactions(w http.ResponseWriter, r *http.Request) {
 		SelectedWalletId: walletId,
 		Wallets:          wallets,
 		Username:         user.Username,
+		IsAdmin:          user.IsAdmin,
 		Title:            "Šparovec | Transactions",
 	}
 
@@ -167,6 +176,7 @@ func (t *Transactions) transactions(w http.ResponseWriter, r *http.Request) {
 		previousPageUrl: templ.SafeURL(prevUrl),
 		nextPageUrl:     templ.SafeURL(nextUrl),
 		urlParams:       r.URL.RawQuery,
+		canEdit:         role.Includes(models.RoleEditor),
 	})
 	err = view.Render(ctx, w)
 	if err != nil {
@@ -179,7 +189,7 @@ func (t *Transactions) saveTransaction(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUser(r)
 	walletId := features.GetWalletId(r)
 
-	if !t.hasPermission(ctx, w, walletId, user.Id) {
+	if !t.hasPermission(ctx, w, walletId, user.Id, models.RoleEditor) {
 		return
 	}
 
@@ -220,7 +230,7 @@ func (t *Transactions) deleteTransaction(w http.ResponseWriter, r *http.Request)
 	user := auth.GetUser(r)
 	walletId := features.GetWalletId(r)
 
-	if !t.hasPermission(ctx, w, walletId, user.Id) {
+	if !t.hasPermission(ctx, w, walletId, user.Id, models.RoleEditor) {
 		return
 	}
 
@@ -231,14 +241,22 @@ func (t *Transactions) deleteTransaction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = t.repository.Delete(ctx, id)
+	err = t.repository.Delete(ctx, walletId, id)
 	if err != nil {
 		t.log.Error("Failed to delete transaction", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set(htmx.HeaderTriggerAfterSettle, htmx.EventDeleteSuccess)
+	undoUrl := fmt.Sprintf("/wallets/%d/trash/restore?entity=%s&id=%d", walletId, models.AuditTransaction, id)
+	events, err := htmx.DeleteWithUndo("Transaction moved to trash.", undoUrl)
+	if err != nil {
+		t.log.Error("Failed to marshal delete events", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(htmx.HeaderTriggerAfterSettle, events)
 	t.transactions(w, r)
 }
 
@@ -247,7 +265,7 @@ func (t *Transactions) importTransactions(w http.ResponseWriter, r *http.Request
 	user := auth.GetUser(r)
 	walletId := features.GetWalletId(r)
 
-	if !t.hasPermission(ctx, w, walletId, user.Id) {
+	if !t.hasPermission(ctx, w, walletId, user.Id, models.RoleEditor) {
 		return
 	}
 
@@ -0,0 +1,255 @@
+// Package trash shows deleted transactions and tags of a wallet, restores them
+// and permanently deletes everything that has been in the trash for too long.
+package trash
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/viddrobnic/sparovec/config"
+	"github.com/viddrobnic/sparovec/features"
+	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/features/htmx"
+	"github.com/viddrobnic/sparovec/models"
+)
+
+const purgeInterval = time.Hour
+
+type TransactionRepository interface {
+	Deleted(ctx context.Context, walletId int) ([]*models.Transaction, error)
+	Restore(ctx context.Context, walletId, id int) error
+	Purge(ctx context.Context, retention time.Duration) (int, error)
+}
+
+type TagRepository interface {
+	Deleted(ctx context.Context, walletId int) ([]*models.Tag, error)
+	Restore(ctx context.Context, walletId, tagId int) error
+	Purge(ctx context.Context, retention time.Duration) (int, error)
+}
+
+type WalletRepository interface {
+	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
+	Role(ctx context.Context, walletId, userId int) (models.Role, error)
+	Purge(ctx context.Context, retention time.Duration) (int, error)
+}
+
+type Trash struct {
+	transactionRepository TransactionRepository
+	tagRepository         TagRepository
+	walletRepository      WalletRepository
+
+	conf *config.Config
+	log  *slog.Logger
+}
+
+func New(
+	transactionRepository TransactionRepository,
+	tagRepository TagRepository,
+	walletRepository WalletRepository,
+	conf *config.Config,
+	log *slog.Logger,
+) *Trash {
+	return &Trash{
+		transactionRepository: transactionRepository,
+		tagRepository:         tagRepository,
+		walletRepository:      walletRepository,
+
+		conf: conf,
+		log:  log,
+	}
+}
+
+func (t *Trash) Mount(router chi.Router) {
+	group := chi.NewRouter()
+	group.Use(auth.RequiredMiddleware)
+
+	group.Get("/", t.trash)
+	group.Post("/restore", t.restore)
+
+	router.Mount("/wallets/{walletId}/trash", group)
+}
+
+func (t *Trash) memberRole(ctx context.Context, w http.ResponseWriter, walletId, userId int) (models.Role, bool) {
+	role, err := t.walletRepository.Role(ctx, walletId, userId)
+	if err != nil {
+		t.log.ErrorContext(ctx, "Failed to get wallet role", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
+	}
+
+	if !role.Includes(models.RoleViewer) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return "", false
+	}
+
+	return role, true
+}
+
+func (t *Trash) trash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+	walletId := features.GetWalletId(r)
+
+	role, ok := t.memberRole(ctx, w, walletId, user.Id)
+	if !ok {
+		return
+	}
+
+	transactions, err := t.transactionRepository.Deleted(ctx, walletId)
+	if err != nil {
+		t.log.ErrorContext(ctx, "Failed to list deleted transactions", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	tags, err := t.tagRepository.Deleted(ctx, walletId)
+	if err != nil {
+		t.log.ErrorContext(ctx, "Failed to list deleted tags", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	wallets, err := t.walletRepository.ForUser(ctx, user.Id)
+	if err != nil {
+		t.log.ErrorContext(ctx, "Failed to get wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	navbar := models.Navbar{
+		SelectedWalletId: walletId,
+		Wallets:          wallets,
+		Username:         user.Username,
+		IsAdmin:          user.IsAdmin,
+		Title:            "Šparovec | Trash",
+	}
+
+	retention := t.conf.Trash.Retention()
+	items := make([]*trashItem, 0, len(transactions)+len(tags))
+	for _, transaction := range transactions {
+		items = append(items, &trashItem{
+			Entity:    models.AuditTransaction,
+			Id:        transaction.Id,
+			Name:      transaction.Name,
+			Details:   models.FormatCurrency(transaction.Value) + ", " + transaction.CreatedAt.Format("02. 01. 2006"),
+			DeletedAt: transaction.DeletedAt.Time,
+			PurgedAt:  transaction.DeletedAt.Time.Add(retention),
+		})
+	}
+	for _, tag := range tags {
+		items = append(items, &trashItem{
+			Entity:    models.AuditTag,
+			Id:        tag.Id,
+			Name:      tag.Name,
+			DeletedAt: tag.DeletedAt.Time,
+			PurgedAt:  tag.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+
+	view := trashView(trashViewData{
+		navbar:  navbar,
+		items:   items,
+		canEdit: role.Includes(models.RoleEditor),
+	})
+	err = view.Render(ctx, w)
+	if err != nil {
+		t.log.ErrorContext(ctx, "Failed to render view", "error", err)
+	}
+}
+
+func (t *Trash) restore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+	walletId := features.GetWalletId(r)
+
+	role, ok := t.memberRole(ctx, w, walletId, user.Id)
+	if !ok {
+		return
+	}
+
+	if !role.Includes(models.RoleEditor) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	switch models.AuditEntity(r.FormValue("entity")) {
+	case models.AuditTransaction:
+		err = t.transactionRepository.Restore(ctx, walletId, id)
+	case models.AuditTag:
+		err = t.tagRepository.Restore(ctx, walletId, id)
+	default:
+		http.Error(w, "Invalid entity", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		t.log.ErrorContext(ctx, "Failed to restore from trash", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Undo is requested with htmx from any page, which is refreshed to show the restored entity.
+	if r.Header.Get(htmx.HeaderRequest) == "true" {
+		w.Header().Set(htmx.HeaderRefresh, "true")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/wallets/%d/trash", walletId), http.StatusSeeOther)
+}
+
+// PurgePeriodically permanently deletes everything that has been in the trash
+// for longer than the configured retention, until the context is done.
+func (t *Trash) PurgePeriodically(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		t.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Trash) purge(ctx context.Context) {
+	retention := t.conf.Trash.Retention()
+	purgers := []struct {
+		name  string
+		purge func(ctx context.Context, retention time.Duration) (int, error)
+	}{
+		{"transactions", t.transactionRepository.Purge},
+		{"tags", t.tagRepository.Purge},
+		{"wallets", t.walletRepository.Purge},
+	}
+
+	for _, purger := range purgers {
+		purged, err := purger.purge(ctx, retention)
+		if err != nil {
+			t.log.ErrorContext(ctx, "Failed to purge trash", "entity", purger.name, "error", err)
+			continue
+		}
+
+		if purged > 0 {
+			t.log.InfoContext(ctx, "Purged trash", "entity", purger.name, "count", purged)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package wallets
+
+import (
+	"context"
+	"time"
+
+	"github.com/viddrobnic/sparovec/models"
+)
+
+// walletTotals are the totals of one wallet in the overview.
+type walletTotals struct {
+	wallet *models.Wallet
+	// Sum of all transactions of the wallet.
+	balance int
+	totals  models.Totals
+}
+
+// overviewData combines all wallets of the user.
+type overviewData struct {
+	dateRange models.DateRange
+	wallets   []walletTotals
+	// Sum of all transactions of all wallets.
+	balance    int
+	totals     models.Totals
+	tagBalance []models.TagBalance
+}
+
+// overview sums the wallets over the current month. Transfers between the wallets aren't
+// income or expenses of the household, so they are left out of the totals.
+func (wlts *Wallets) overview(ctx context.Context, wallets []*models.Wallet) (*overviewData, error) {
+	year, month, _ := time.Now().Date()
+	from := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	data := &overviewData{
+		dateRange: models.DateRange{From: from, To: from.AddDate(0, 1, -1)},
+		wallets:   make([]walletTotals, len(wallets)),
+	}
+	if len(wallets) == 0 {
+		return data, nil
+	}
+
+	walletIds := make([]int, len(wallets))
+	for i, wallet := range wallets {
+		walletIds[i] = wallet.Id
+	}
+
+	balances, err := wlts.repository.Balances(ctx, walletIds)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := wlts.repository.Totals(ctx, walletIds, data.dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	data.tagBalance, err = wlts.repository.TagBalances(ctx, walletIds, data.dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, wallet := range wallets {
+		data.wallets[i] = walletTotals{
+			wallet:  wallet,
+			balance: balances[wallet.Id],
+			totals:  totals[wallet.Id],
+		}
+
+		data.balance += balances[wallet.Id]
+		data.totals.Income += totals[wallet.Id].Income
+		data.totals.Outcome += totals[wallet.Id].Outcome
+		data.totals.NrTransactions += totals[wallet.Id].NrTransactions
+	}
+
+	for i := range data.tagBalance {
+		if data.tagBalance[i].Tag == nil {
+			data.tagBalance[i].Tag = models.OtherTag(0)
+		}
+	}
+
+	return data, nil
+}
@@ -3,9 +3,13 @@ package wallets
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/database"
+	"github.com/viddrobnic/sparovec/features/audit"
 	"github.com/viddrobnic/sparovec/models"
 )
 
@@ -18,10 +22,11 @@ func NewRepository(db *sqlx.DB) *Repository {
 }
 
 func (w *Repository) ForUser(ctx context.Context, userId int) ([]*models.Wallet, error) {
-	builder := sq.Select("w.*").
+	builder := sq.Select("w.*", "wu.role").
 		From("wallets w").
 		Join("wallet_users wu ON w.id = wu.wallet_id").
 		Where("wu.user_id = ?", userId).
+		Where("w.deleted_at IS NULL").
 		OrderBy("w.created_at desc", "w.id")
 
 	stmt, args, err := builder.ToSql()
@@ -34,8 +39,23 @@ func (w *Repository) ForUser(ctx context.Context, userId int) ([]*models.Wallet,
 	return wallets, err
 }
 
+// List returns all wallets, including the ones in the trash.
+func (w *Repository) List(ctx context.Context) ([]*models.Wallet, error) {
+	builder := sq.Select("*").From("wallets").OrderBy("id")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	wallets := []*models.Wallet{}
+	err = w.db.SelectContext(ctx, &wallets, stmt, args...)
+	return wallets, err
+}
+
+// ForId returns the wallet, or nil if it doesn't exist or is in the trash.
 func (w *Repository) ForId(ctx context.Context, walletId int) (*models.Wallet, error) {
-	builder := sq.Select("*").From("wallets").Where("id = ?", walletId)
+	builder := sq.Select("*").From("wallets").Where("id = ?", walletId).Where("deleted_at IS NULL")
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
@@ -52,19 +72,75 @@ func (w *Repository) ForId(ctx context.Context, walletId int) (*models.Wallet, e
 }
 
 func (w *Repository) SetName(ctx context.Context, walletId int, name string) error {
-	builder := sq.Update("wallets").Set("name", name).Where("id = ?", walletId)
+	return w.change(ctx, walletId, models.AuditWallet, walletId, models.AuditUpdate, func(tx *sqlx.Tx) error {
+		builder := sq.Update("wallets").Set("name", name).Where("id = ?", walletId)
 
-	stmt, args, err := builder.ToSql()
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
+		return err
+	})
+}
+
+// SetPeriodStartDay sets the day of the month on which periods of the wallet start.
+func (w *Repository) SetPeriodStartDay(ctx context.Context, walletId, day int) error {
+	return w.change(ctx, walletId, models.AuditWallet, walletId, models.AuditUpdate, func(tx *sqlx.Tx) error {
+		builder := sq.Update("wallets").Set("period_start_day", day).Where("id = ?", walletId)
+
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
+		return err
+	})
+}
+
+// change runs the mutation in a transaction and records the change of the entity.
+// Updates and deletes of entities that don't exist are neither run nor recorded.
+func (w *Repository) change(
+	ctx context.Context,
+	walletId int,
+	entity models.AuditEntity,
+	entityId int,
+	action models.AuditAction,
+	mutate func(tx *sqlx.Tx) error,
+) error {
+	tx, err := w.db.Beginx()
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	_, err = w.db.ExecContext(ctx, stmt, args...)
-	return err
+	var before []models.AuditField
+	if action != models.AuditCreate {
+		before, err = audit.Fields(ctx, tx, walletId, entity, entityId)
+		if err != nil || before == nil {
+			return err
+		}
+	}
+
+	err = mutate(tx)
+	if err != nil {
+		return err
+	}
+
+	err = audit.RecordChange(ctx, tx, walletId, entity, entityId, action, before)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (w *Repository) Members(ctx context.Context, walletId int) ([]*models.Member, error) {
-	builder := sq.Select("u.id", "u.username").
+	builder := sq.Select("u.id", "u.username", "wu.role").
 		From("users u").
 		InnerJoin("wallet_users wu ON u.id = wu.user_id").
 		Where("wu.wallet_id = ?", walletId).
@@ -80,58 +156,94 @@ func (w *Repository) Members(ctx context.Context, walletId int) ([]*models.Membe
 	return members, err
 }
 
-func (w *Repository) AddMember(ctx context.Context, walletId, userId int) error {
-	builder := sq.Insert("wallet_users").
-		Columns("wallet_id", "user_id").
-		Values(walletId, userId)
+func (w *Repository) AddMember(ctx context.Context, walletId, userId int, role models.Role) error {
+	return w.change(ctx, walletId, models.AuditMember, userId, models.AuditCreate, func(tx *sqlx.Tx) error {
+		builder := sq.Insert("wallet_users").
+			Columns("wallet_id", "user_id", "role").
+			Values(walletId, userId, role)
 
-	stmt, args, err := builder.ToSql()
-	if err != nil {
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
 		return err
-	}
+	})
+}
 
-	_, err = w.db.ExecContext(ctx, stmt, args...)
-	return err
+func (w *Repository) SetRole(ctx context.Context, walletId, userId int, role models.Role) error {
+	return w.change(ctx, walletId, models.AuditMember, userId, models.AuditUpdate, func(tx *sqlx.Tx) error {
+		builder := sq.Update("wallet_users").
+			Set("role", role).
+			Where(sq.Eq{
+				"wallet_id": walletId,
+				"user_id":   userId,
+			})
+
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
+		return err
+	})
 }
 
-func (w *Repository) RemoveMember(ctx context.Context, walletId int, userId string) error {
-	builder := sq.Delete("wallet_users").
-		Where(sq.Eq{
-			"wallet_id": walletId,
-			"user_id":   userId,
-		})
+func (w *Repository) RemoveMember(ctx context.Context, walletId, userId int) error {
+	return w.change(ctx, walletId, models.AuditMember, userId, models.AuditDelete, func(tx *sqlx.Tx) error {
+		builder := sq.Delete("wallet_users").
+			Where(sq.Eq{
+				"wallet_id": walletId,
+				"user_id":   userId,
+			})
 
-	stmt, args, err := builder.ToSql()
-	if err != nil {
-		return err
-	}
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
 
-	_, err = w.db.ExecContext(ctx, stmt, args...)
-	return err
+		_, err = tx.ExecContext(ctx, stmt, args...)
+		return err
+	})
 }
 
-func (w *Repository) HasPermission(ctx context.Context, walletId, userId int) (bool, error) {
-	builder := sq.Select("1").
-		From("wallet_users").
+// Role returns the role of the user in the wallet,
+// or an empty role if the user is not a member or the wallet is in the trash.
+func (w *Repository) Role(ctx context.Context, walletId, userId int) (models.Role, error) {
+	builder := sq.Select("wu.role").
+		From("wallet_users wu").
+		InnerJoin("wallets w ON w.id = wu.wallet_id").
 		Where(sq.Eq{
-			"wallet_id": walletId,
-			"user_id":   userId,
-		}).Limit(1)
+			"wu.wallet_id": walletId,
+			"wu.user_id":   userId,
+			"w.deleted_at": nil,
+		})
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
-	var exists bool
-	err = w.db.GetContext(ctx, &exists, stmt, args...)
+	var role models.Role
+	err = w.db.GetContext(ctx, &role, stmt, args...)
 	if err == sql.ErrNoRows {
-		exists = false
-	} else if err != nil {
+		return "", nil
+	}
+
+	return role, err
+}
+
+// HasPermission returns true if the user is a member of the wallet
+// with at least the given role.
+func (w *Repository) HasPermission(ctx context.Context, walletId, userId int, role models.Role) (bool, error) {
+	memberRole, err := w.Role(ctx, walletId, userId)
+	if err != nil {
 		return false, err
 	}
 
-	return exists, nil
+	return memberRole.Includes(role), nil
 }
 
 func (w *Repository) Create(ctx context.Context, userId int, name string) (*models.Wallet, error) {
@@ -158,7 +270,9 @@ func (w *Repository) Create(ctx context.Context, userId int, name string) (*mode
 	}
 
 	// Insert wallet user
-	builder = sq.Insert("wallet_users").Columns("user_id", "wallet_id").Values(userId, wallet.Id)
+	builder = sq.Insert("wallet_users").
+		Columns("user_id", "wallet_id", "role").
+		Values(userId, wallet.Id, models.RoleOwner)
 
 	stmt, args, err = builder.ToSql()
 	if err != nil {
@@ -170,18 +284,450 @@ func (w *Repository) Create(ctx context.Context, userId int, name string) (*mode
 		return nil, err
 	}
 
+	err = audit.RecordChange(ctx, tx, wallet.Id, models.AuditWallet, wallet.Id, models.AuditCreate, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = audit.RecordChange(ctx, tx, wallet.Id, models.AuditMember, userId, models.AuditCreate, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	err = tx.Commit()
 	return wallet, err
 }
 
+// Delete moves the wallet to the trash.
 func (w *Repository) Delete(ctx context.Context, walletId int) error {
-	builder := sq.Delete("wallets").Where("id = ?", walletId)
+	return w.setDeleted(ctx, walletId, true)
+}
+
+// Restore restores the wallet from the trash.
+func (w *Repository) Restore(ctx context.Context, walletId int) error {
+	return w.setDeleted(ctx, walletId, false)
+}
+
+func (w *Repository) setDeleted(ctx context.Context, walletId int, deleted bool) error {
+	action := models.AuditRestore
+	if deleted {
+		action = models.AuditDelete
+	}
+
+	return w.change(ctx, walletId, models.AuditWallet, walletId, action, func(tx *sqlx.Tx) error {
+		builder := sq.Update("wallets").Where("id = ?", walletId)
+		if deleted {
+			builder = builder.Set("deleted_at", sq.Expr("datetime('now')"))
+		} else {
+			builder = builder.Set("deleted_at", nil)
+		}
+
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
+		return err
+	})
+}
+
+// DeletedForOwner returns the wallets in the trash that the user owns,
+// most recently deleted first.
+func (w *Repository) DeletedForOwner(ctx context.Context, userId int) ([]*models.Wallet, error) {
+	builder := sq.Select("w.*", "wu.role").
+		From("wallets w").
+		Join("wallet_users wu ON w.id = wu.wallet_id").
+		Where(sq.Eq{"wu.user_id": userId, "wu.role": models.RoleOwner}).
+		Where("w.deleted_at IS NOT NULL").
+		OrderBy("w.deleted_at DESC", "w.id DESC")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	wallets := []*models.Wallet{}
+	err = w.db.SelectContext(ctx, &wallets, stmt, args...)
+	return wallets, err
+}
+
+// Purge permanently deletes wallets that have been in the trash for longer than the retention.
+func (w *Repository) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	builder := sq.Delete("wallets").
+		Where("deleted_at < datetime('now', ?)", fmt.Sprintf("-%d seconds", int(retention.Seconds())))
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := w.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+	return int(purged), err
+}
+
+func (w *Repository) CreateInvitation(ctx context.Context, walletId, userId, invitedBy int, role models.Role) error {
+	tx, err := w.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := sq.Insert("wallet_invitations").
+		Columns("wallet_id", "user_id", "invited_by", "role").
+		Values(walletId, userId, invitedBy, role).
+		Suffix("RETURNING id")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	var invitationId int
+	err = tx.GetContext(ctx, &invitationId, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	err = audit.RecordChange(ctx, tx, walletId, models.AuditInvitation, invitationId, models.AuditCreate, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func invitationsQuery() sq.SelectBuilder {
+	return sq.Select(
+		"i.id",
+		"i.wallet_id",
+		"w.name AS wallet_name",
+		"u.username",
+		"inviter.username AS invited_by",
+		"i.role",
+		"i.created_at",
+	).
+		From("wallet_invitations i").
+		InnerJoin("wallets w ON w.id = i.wallet_id").
+		InnerJoin("users u ON u.id = i.user_id").
+		LeftJoin("users inviter ON inviter.id = i.invited_by").
+		Where("w.deleted_at IS NULL").
+		OrderBy("i.created_at DESC", "i.id DESC")
+}
+
+// Invitations returns the pending invitations to the wallet.
+func (w *Repository) Invitations(ctx context.Context, walletId int) ([]*models.Invitation, error) {
+	builder := invitationsQuery().Where("i.wallet_id = ?", walletId)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := []*models.Invitation{}
+	err = w.db.SelectContext(ctx, &invitations, stmt, args...)
+	return invitations, err
+}
+
+// InvitationsForUser returns the pending invitations of the user.
+func (w *Repository) InvitationsForUser(ctx context.Context, userId int) ([]*models.Invitation, error) {
+	builder := invitationsQuery().Where("i.user_id = ?", userId)
 
 	stmt, args, err := builder.ToSql()
 	if err != nil {
+		return nil, err
+	}
+
+	invitations := []*models.Invitation{}
+	err = w.db.SelectContext(ctx, &invitations, stmt, args...)
+	return invitations, err
+}
+
+func (w *Repository) CountInvitationsForUser(ctx context.Context, userId int) (int, error) {
+	builder := sq.Select("COUNT(*)").
+		From("wallet_invitations i").
+		InnerJoin("wallets w ON w.id = i.wallet_id").
+		Where("i.user_id = ?", userId).
+		Where("w.deleted_at IS NULL")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = w.db.GetContext(ctx, &count, stmt, args...)
+	return count, err
+}
+
+// AcceptInvitation makes the user a member of the wallet with the role from
+// the invitation and deletes the invitation. It returns nil if the user
+// doesn't have such an invitation.
+func (w *Repository) AcceptInvitation(ctx context.Context, invitationId, userId int) (*models.Invitation, error) {
+	tx, err := w.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := invitationsQuery().Where(sq.Eq{"i.id": invitationId, "i.user_id": userId})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.Invitation{}
+	err = tx.GetContext(ctx, invitation, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	invitationFields, err := audit.InvitationFields(ctx, tx, invitation.WalletId, invitationId)
+	if err != nil {
+		return nil, err
+	}
+
+	memberFields, err := audit.MemberFields(ctx, tx, invitation.WalletId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user might have been added in the meantime with the cli.
+	insertBuilder := sq.Insert("wallet_users").
+		Options("OR IGNORE").
+		Columns("user_id", "wallet_id", "role").
+		Values(userId, invitation.WalletId, invitation.Role)
+
+	stmt, args, err = insertBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteBuilder := sq.Delete("wallet_invitations").Where("id = ?", invitationId)
+
+	stmt, args, err = deleteBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = audit.RecordChange(ctx, tx, invitation.WalletId, models.AuditInvitation, invitationId, models.AuditDelete, invitationFields)
+	if err != nil {
+		return nil, err
+	}
+
+	if memberFields == nil {
+		err = audit.RecordChange(ctx, tx, invitation.WalletId, models.AuditMember, userId, models.AuditCreate, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return invitation, tx.Commit()
+}
+
+// DeclineInvitation deletes the invitation of the user.
+func (w *Repository) DeclineInvitation(ctx context.Context, invitationId, userId int) error {
+	builder := sq.Select("wallet_id").From("wallet_invitations").Where(sq.Eq{
+		"id":      invitationId,
+		"user_id": userId,
+	})
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	var walletId int
+	err = w.db.GetContext(ctx, &walletId, stmt, args...)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return w.deleteInvitation(ctx, walletId, invitationId)
+}
+
+// CancelInvitation deletes a pending invitation to the wallet.
+func (w *Repository) CancelInvitation(ctx context.Context, walletId, invitationId int) error {
+	return w.deleteInvitation(ctx, walletId, invitationId)
+}
+
+func (w *Repository) deleteInvitation(ctx context.Context, walletId, invitationId int) error {
+	return w.change(ctx, walletId, models.AuditInvitation, invitationId, models.AuditDelete, func(tx *sqlx.Tx) error {
+		builder := sq.Delete("wallet_invitations").Where(sq.Eq{
+			"id":        invitationId,
+			"wallet_id": walletId,
+		})
+
+		stmt, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, args...)
 		return err
+	})
+}
+
+// notTransfer leaves out transfers between the wallets: transactions with a transaction of the
+// opposite value on the same day in another of the wallets. It's a heuristic with false positives,
+// like a refund in one wallet on the day of an equal payment in another, which are left out too.
+func notTransfer(walletIds []int) sq.Sqlizer {
+	args := make([]any, len(walletIds))
+	for i, id := range walletIds {
+		args[i] = id
+	}
+
+	return sq.Expr(`NOT EXISTS (
+		SELECT 1 FROM transactions o
+		WHERE o.wallet_id IN (`+sq.Placeholders(len(walletIds))+`)
+			AND o.wallet_id != tr.wallet_id
+			AND o.deleted_at IS NULL
+			AND o.value = -tr.value
+			AND o.created_at >= date(tr.created_at)
+			AND o.created_at < date(tr.created_at, '+1 day')
+	)`, args...)
+}
+
+// Balances returns the sum of all transactions of each wallet.
+func (w *Repository) Balances(ctx context.Context, walletIds []int) (map[int]int, error) {
+	builder := sq.Select("tr.wallet_id", "COALESCE(SUM(tr.value), 0) AS balance").
+		From("transactions tr").
+		Where(sq.Eq{
+			"tr.wallet_id":  walletIds,
+			"tr.deleted_at": nil,
+		}).
+		GroupBy("tr.wallet_id")
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		WalletId int `db:"wallet_id"`
+		Balance  int
+	}{}
+	err = w.db.SelectContext(ctx, &rows, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[int]int, len(rows))
+	for _, row := range rows {
+		balances[row.WalletId] = row.Balance
+	}
+
+	return balances, nil
+}
+
+// Totals returns the sums of the transactions of each wallet in the range,
+// without transfers between the wallets.
+func (w *Repository) Totals(ctx context.Context, walletIds []int, dateRange models.DateRange) (map[int]models.Totals, error) {
+	builder := sq.Select(
+		"tr.wallet_id",
+		"COALESCE(SUM(CASE WHEN tr.value > 0 THEN tr.value END), 0) AS income",
+		"COALESCE(SUM(CASE WHEN tr.value < 0 THEN tr.value END), 0) AS outcome",
+		"COUNT(*) AS nr_transactions",
+	).
+		From("transactions tr").
+		Where(sq.Eq{
+			"tr.wallet_id":  walletIds,
+			"tr.deleted_at": nil,
+		}).
+		Where(notTransfer(walletIds)).
+		GroupBy("tr.wallet_id")
+	builder = database.InRange(builder, "tr.created_at", dateRange)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		WalletId int `db:"wallet_id"`
+		models.Totals
+	}{}
+	err = w.db.SelectContext(ctx, &rows, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[int]models.Totals, len(rows))
+	for _, row := range rows {
+		totals[row.WalletId] = row.Totals
+	}
+
+	return totals, nil
+}
+
+// TagBalances returns the sums of expenses of the wallets in the range for each tag,
+// without transfers between the wallets. Tags with the same name in different wallets
+// are merged. Expenses without a tag, or with a tag in the trash, have no tag.
+func (w *Repository) TagBalances(ctx context.Context, walletIds []int, dateRange models.DateRange) ([]models.TagBalance, error) {
+	builder := sq.Select("MIN(t.name) AS name", "MIN(t.color) AS color", "MIN(t.icon) AS icon", "SUM(tr.value) AS balance").
+		From("transactions tr").
+		LeftJoin("tags t ON t.id = tr.tag_id AND t.deleted_at IS NULL").
+		Where(sq.Eq{
+			"tr.wallet_id":  walletIds,
+			"tr.deleted_at": nil,
+		}).
+		Where("tr.value < 0").
+		Where(notTransfer(walletIds)).
+		GroupBy("lower(t.name)").
+		OrderBy("balance")
+	builder = database.InRange(builder, "tr.created_at", dateRange)
+
+	stmt, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		Name    sql.NullString
+		Color   sql.NullString
+		Icon    sql.NullString
+		Balance int
+	}{}
+	err = w.db.SelectContext(ctx, &rows, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]models.TagBalance, len(rows))
+	for i, row := range rows {
+		balances[i].Balance = row.Balance
+		if row.Name.Valid {
+			balances[i].Tag = &models.Tag{
+				Name:  row.Name.String,
+				Color: row.Color.String,
+				Icon:  row.Icon.String,
+			}
+		}
 	}
 
-	_, err = w.db.ExecContext(ctx, stmt, args...)
-	return err
+	return balances, nil
 }
@@ -2,11 +2,16 @@ package wallets
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/viddrobnic/sparovec/features"
 	"github.com/viddrobnic/sparovec/features/auth"
+	"github.com/viddrobnic/sparovec/features/htmx"
 	"github.com/viddrobnic/sparovec/models"
 )
 
@@ -16,35 +21,52 @@ func (wlts *Wallets) mountSettings(router chi.Router) {
 
 	group.Get("/", wlts.settings)
 	group.Post("/name", wlts.settingsSaveName)
-	group.Post("/add-member", wlts.settingsAddMember)
+	group.Post("/period-start", wlts.settingsSavePeriodStart)
+	group.Post("/invite-member", wlts.settingsInviteMember)
+	group.Post("/cancel-invitation", wlts.settingsCancelInvitation)
+	group.Post("/member-role", wlts.settingsSetMemberRole)
 	group.Post("/remove-member", wlts.settingsRemoveMember)
 	group.Post("/delete", wlts.settingsDeleteWallet)
 
 	router.Mount("/wallets/{walletId}/settings", group)
 }
 
-func (wlts *Wallets) hasPermission(ctx context.Context, w http.ResponseWriter, walletId, userId int) bool {
-	hasPermission, err := wlts.repository.HasPermission(ctx, walletId, userId)
+// memberRole returns the role of the user in the wallet. If the user
+// doesn't have at least the required role, an error is written and false is returned.
+func (wlts *Wallets) memberRole(ctx context.Context, w http.ResponseWriter, walletId, userId int, required models.Role) (models.Role, bool) {
+	role, err := wlts.repository.Role(ctx, walletId, userId)
 	if err != nil {
-		wlts.log.ErrorContext(ctx, "Failed to get wallet permission", "error", err)
+		wlts.log.ErrorContext(ctx, "Failed to get wallet role", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return false
+		return "", false
 	}
 
-	if !hasPermission {
+	if !role.Includes(required) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
-		return false
+		return "", false
 	}
 
-	return true
+	return role, true
+}
+
+func (wlts *Wallets) hasPermission(ctx context.Context, w http.ResponseWriter, walletId, userId int, required models.Role) bool {
+	_, ok := wlts.memberRole(ctx, w, walletId, userId, required)
+	return ok
 }
 
 func (wlts *Wallets) settings(w http.ResponseWriter, r *http.Request) {
+	wlts.renderSettings(w, r, settingsViewData{})
+}
+
+// renderSettings renders the settings page. Messages of the members
+// form are taken from data, the rest is loaded here.
+func (wlts *Wallets) renderSettings(w http.ResponseWriter, r *http.Request, data settingsViewData) {
 	ctx := r.Context()
 	walletId := features.GetWalletId(r)
 	user := auth.GetUser(r)
 
-	if !wlts.hasPermission(ctx, w, walletId, user.Id) {
+	role, ok := wlts.memberRole(ctx, w, walletId, user.Id, models.RoleViewer)
+	if !ok {
 		return
 	}
 
@@ -69,17 +91,28 @@ func (wlts *Wallets) settings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := settingsViewData{
-		Navbar: models.Navbar{
-			SelectedWalletId: walletId,
-			Wallets:          wallets,
-			Username:         user.Username,
-			Title:            "Šparovec | Settings",
-		},
-		Wallet:  wallet,
-		Members: sortMembers(members, user),
+	invitations := []*models.Invitation{}
+	if role == models.RoleOwner {
+		invitations, err = wlts.repository.Invitations(ctx, walletId)
+		if err != nil {
+			wlts.log.ErrorContext(ctx, "Failed to get invitations", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 	}
 
+	data.Navbar = models.Navbar{
+		SelectedWalletId: walletId,
+		Wallets:          wallets,
+		Username:         user.Username,
+		IsAdmin:          user.IsAdmin,
+		Title:            "Šparovec | Settings",
+	}
+	data.Wallet = wallet
+	data.Members = sortMembers(members, user)
+	data.Invitations = invitations
+	data.IsOwner = role == models.RoleOwner
+
 	view := settingsView(data)
 	err = view.Render(ctx, w)
 	if err != nil {
@@ -92,7 +125,7 @@ func (wlts *Wallets) settingsSaveName(w http.ResponseWriter, r *http.Request) {
 	walletId := features.GetWalletId(r)
 	user := auth.GetUser(r)
 
-	if !wlts.hasPermission(ctx, w, walletId, user.Id) {
+	if !wlts.hasPermission(ctx, w, walletId, user.Id, models.RoleOwner) {
 		return
 	}
 
@@ -107,35 +140,176 @@ func (wlts *Wallets) settingsSaveName(w http.ResponseWriter, r *http.Request) {
 	wlts.settings(w, r)
 }
 
-func (wlts *Wallets) settingsAddMember(w http.ResponseWriter, r *http.Request) {
+func (wlts *Wallets) settingsSavePeriodStart(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	walletId := features.GetWalletId(r)
 	user := auth.GetUser(r)
 
-	if !wlts.hasPermission(ctx, w, walletId, user.Id) {
+	if !wlts.hasPermission(ctx, w, walletId, user.Id, models.RoleOwner) {
+		return
+	}
+
+	day, err := strconv.Atoi(r.FormValue("day"))
+	if err != nil || day < 1 || day > models.MaxPeriodStartDay {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	err = wlts.repository.SetPeriodStartDay(ctx, walletId, day)
+	if err != nil {
+		wlts.log.ErrorContext(ctx, "Failed to set period start day", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	wlts.settings(w, r)
+}
+
+func (wlts *Wallets) settingsInviteMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	walletId := features.GetWalletId(r)
+	user := auth.GetUser(r)
+
+	if !wlts.hasPermission(ctx, w, walletId, user.Id, models.RoleOwner) {
+		return
+	}
+
+	role := models.Role(r.FormValue("role"))
+	if !role.IsValid() {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	username := strings.TrimSpace(r.FormValue("username"))
+	invitee, err := wlts.validateInvitation(ctx, walletId, username)
+	var invalidForm *models.ErrInvalidForm
+	if errors.As(err, &invalidForm) {
+		wlts.renderSettings(w, r, settingsViewData{MemberError: invalidForm.Message})
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	username := r.FormValue("username")
+	err = wlts.repository.CreateInvitation(ctx, walletId, invitee.Id, user.Id, role)
+	if err != nil {
+		wlts.log.ErrorContext(ctx, "Failed to create invitation", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	wlts.log.InfoContext(ctx, "Invited user to wallet", "wallet_id", walletId, "user_id", invitee.Id, "by", user.Id)
+	wlts.renderSettings(w, r, settingsViewData{
+		MemberMessage: fmt.Sprintf("Invited %s. They become a member once they accept the invitation.", username),
+	})
+}
+
+// validateInvitation checks that the user exists and is not yet
+// a member of the wallet or invited to it. It returns the invited user.
+func (wlts *Wallets) validateInvitation(ctx context.Context, walletId int, username string) (*models.UserCredentials, error) {
+	if username == "" {
+		return nil, &models.ErrInvalidForm{Message: "Enter the username of the user you want to invite."}
+	}
+
 	creds, err := wlts.userRepository.GetByUsername(ctx, username)
 	if err != nil {
 		wlts.log.ErrorContext(ctx, "Failed to get user by username", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if creds == nil {
+		return nil, &models.ErrInvalidForm{Message: fmt.Sprintf("User %s doesn't exist.", username)}
+	}
+
+	role, err := wlts.repository.Role(ctx, walletId, creds.Id)
+	if err != nil {
+		wlts.log.ErrorContext(ctx, "Failed to get wallet role", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	if role != "" {
+		return nil, &models.ErrInvalidForm{Message: fmt.Sprintf("%s is already a member of this wallet.", username)}
+	}
+
+	invitations, err := wlts.repository.Invitations(ctx, walletId)
+	if err != nil {
+		wlts.log.ErrorContext(ctx, "Failed to get invitations", "error", err)
+		return nil, models.ErrInternalServer
+	}
+
+	for _, invitation := range invitations {
+		if invitation.Username == creds.Username {
+			return nil, &models.ErrInvalidForm{Message: fmt.Sprintf("%s is already invited to this wallet.", username)}
+		}
+	}
+
+	return creds, nil
+}
+
+func (wlts *Wallets) settingsCancelInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	walletId := features.GetWalletId(r)
+	user := auth.GetUser(r)
+
+	if !wlts.hasPermission(ctx, w, walletId, user.Id, models.RoleOwner) {
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	err = wlts.repository.CancelInvitation(ctx, walletId, id)
+	if err != nil {
+		wlts.log.ErrorContext(ctx, "Failed to cancel invitation", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	if creds == nil {
-		wlts.settings(w, r)
+	wlts.settings(w, r)
+}
+
+func (wlts *Wallets) settingsSetMemberRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	walletId := features.GetWalletId(r)
+	user := auth.GetUser(r)
+
+	if !wlts.hasPermission(ctx, w, walletId, user.Id, models.RoleOwner) {
+		return
+	}
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
 
-	err = wlts.repository.AddMember(ctx, walletId, creds.Id)
+	role := models.Role(r.FormValue("role"))
+	if !role.IsValid() {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if role != models.RoleOwner && !wlts.hasOtherOwner(ctx, w, r, walletId, id) {
+		return
+	}
+
+	err = wlts.repository.SetRole(ctx, walletId, id, role)
 	if err != nil {
-		wlts.log.ErrorContext(ctx, "Failed to add member", "error", err)
+		wlts.log.ErrorContext(ctx, "Failed to set member role", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	// Owners that demote themselves can't see the settings
+	// of other members anymore, so the whole page is reloaded.
+	if id == user.Id {
+		w.Header().Set(htmx.HeaderRefresh, "true")
+		return
+	}
+
 	wlts.settings(w, r)
 }
 
@@ -144,12 +318,21 @@ func (wlts *Wallets) settingsRemoveMember(w http.ResponseWriter, r *http.Request
 	walletId := features.GetWalletId(r)
 	user := auth.GetUser(r)
 
-	if !wlts.hasPermission(ctx, w, walletId, user.Id) {
+	if !wlts.hasPermission(ctx, w, walletId, user.Id, models.RoleOwner) {
 		return
 	}
 
-	id := r.FormValue("id")
-	err := wlts.repository.RemoveMember(ctx, walletId, id)
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if !wlts.hasOtherOwner(ctx, w, r, walletId, id) {
+		return
+	}
+
+	err = wlts.repository.RemoveMember(ctx, walletId, id)
 	if err != nil {
 		wlts.log.ErrorContext(ctx, "Failed to remove member", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -159,12 +342,30 @@ func (wlts *Wallets) settingsRemoveMember(w http.ResponseWriter, r *http.Request
 	wlts.settings(w, r)
 }
 
+// hasOtherOwner checks that the wallet still has an owner when the user
+// stops being one. Otherwise the settings are rendered with an error.
+func (wlts *Wallets) hasOtherOwner(ctx context.Context, w http.ResponseWriter, r *http.Request, walletId, userId int) bool {
+	members, err := wlts.repository.Members(ctx, walletId)
+	if err != nil {
+		wlts.log.ErrorContext(ctx, "Failed to get members", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+
+	if models.IsLastOwner(members, userId) {
+		wlts.renderSettings(w, r, settingsViewData{MemberError: "A wallet must have at least one owner."})
+		return false
+	}
+
+	return true
+}
+
 func (wlts *Wallets) settingsDeleteWallet(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	walletId := features.GetWalletId(r)
 	user := auth.GetUser(r)
 
-	if !wlts.hasPermission(ctx, w, walletId, user.Id) {
+	if !wlts.hasPermission(ctx, w, walletId, user.Id, models.RoleOwner) {
 		return
 	}
 
@@ -175,7 +376,7 @@ func (wlts *Wallets) settingsDeleteWallet(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	http.Redirect(w, r, fmt.Sprintf("/?deleted=%d", walletId), http.StatusSeeOther)
 }
 
 func sortMembers(members []*models.Member, user *models.User) []*models.Member {
@@ -2,8 +2,10 @@ package wallets
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/viddrobnic/sparovec/features"
@@ -15,13 +17,22 @@ import (
 type RepositoryInterface interface {
 	ForUser(ctx context.Context, userId int) ([]*models.Wallet, error)
 	Create(ctx context.Context, userId int, name string) (*models.Wallet, error)
-	HasPermission(ctx context.Context, walletId, userId int) (bool, error)
+	Role(ctx context.Context, walletId, userId int) (models.Role, error)
 	ForId(ctx context.Context, walletId int) (*models.Wallet, error)
 	Members(ctx context.Context, walletId int) ([]*models.Member, error)
 	SetName(ctx context.Context, walletId int, name string) error
-	AddMember(ctx context.Context, walletId, userId int) error
-	RemoveMember(ctx context.Context, walletId int, userId string) error
+	SetPeriodStartDay(ctx context.Context, walletId, day int) error
+	CreateInvitation(ctx context.Context, walletId, userId, invitedBy int, role models.Role) error
+	Invitations(ctx context.Context, walletId int) ([]*models.Invitation, error)
+	CancelInvitation(ctx context.Context, walletId, invitationId int) error
+	SetRole(ctx context.Context, walletId, userId int, role models.Role) error
+	RemoveMember(ctx context.Context, walletId, userId int) error
 	Delete(ctx context.Context, walletId int) error
+	Restore(ctx context.Context, walletId int) error
+	DeletedForOwner(ctx context.Context, userId int) ([]*models.Wallet, error)
+	Balances(ctx context.Context, walletIds []int) (map[int]int, error)
+	Totals(ctx context.Context, walletIds []int, dateRange models.DateRange) (map[int]models.Totals, error)
+	TagBalances(ctx context.Context, walletIds []int, dateRange models.DateRange) ([]models.TagBalance, error)
 }
 
 type UserRepository interface {
@@ -53,6 +64,7 @@ func (wlts *Wallets) Mount(router chi.Router) {
 
 	group.Get("/", wlts.wallets)
 	group.Post("/", wlts.createWallet)
+	group.Post("/restore", wlts.restoreWallet)
 
 	router.Mount("/", group)
 
@@ -69,20 +81,90 @@ func (wlts *Wallets) wallets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	deletedWallets, err := wlts.repository.DeletedForOwner(r.Context(), user.Id)
+	if err != nil {
+		wlts.log.Error("Failed to get deleted wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	overview, err := wlts.overview(r.Context(), wallets)
+	if err != nil {
+		wlts.log.Error("Failed to get overview", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Set after the wallet was deleted in the settings, to offer an undo.
+	var undoWallet *models.Wallet
+	deletedId, _ := strconv.Atoi(r.URL.Query().Get("deleted"))
+	for _, wallet := range deletedWallets {
+		if wallet.Id == deletedId {
+			undoWallet = wallet
+		}
+	}
+
 	navbar := models.Navbar{
 		SelectedWalletId: features.GetWalletId(r),
 		Wallets:          wallets,
 		Username:         user.Username,
+		IsAdmin:          user.IsAdmin,
 		Title:            "Šparovec",
 	}
 
-	view := walletsView(wallets, navbar)
+	view := walletsView(overview, deletedWallets, undoWallet, navbar)
 	err = view.Render(r.Context(), w)
 	if err != nil {
 		wlts.log.Error("Failed to render view", "error", err)
 	}
 }
 
+// restoreWallet restores a wallet from the trash. Only owners can restore wallets.
+func (wlts *Wallets) restoreWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := auth.GetUser(r)
+
+	walletId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	deletedWallets, err := wlts.repository.DeletedForOwner(ctx, user.Id)
+	if err != nil {
+		wlts.log.ErrorContext(ctx, "Failed to get deleted wallets", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	isOwner := false
+	for _, wallet := range deletedWallets {
+		if wallet.Id == walletId {
+			isOwner = true
+		}
+	}
+
+	if !isOwner {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err = wlts.repository.Restore(ctx, walletId)
+	if err != nil {
+		wlts.log.ErrorContext(ctx, "Failed to restore wallet", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	walletUrl := fmt.Sprintf("/wallets/%d", walletId)
+	if r.Header.Get(htmx.HeaderRequest) == "true" {
+		w.Header().Set(htmx.HeaderRedirect, walletUrl)
+		return
+	}
+
+	http.Redirect(w, r, walletUrl, http.StatusSeeOther)
+}
+
 func (wlts Wallets) createWallet(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUser(r)
 	name := r.FormValue("name")
@@ -95,7 +177,7 @@ func (wlts Wallets) createWallet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set(htmx.HeaderTriggerAfterSettle, htmx.EventCreateSuccess)
-	view := walletCard(wallet)
+	view := walletCard(walletTotals{wallet: wallet})
 	err = view.Render(r.Context(), w)
 	if err != nil {
 		wlts.log.Error("Failed to render view", "error", err)
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"embed"
+	"flag"
 	"fmt"
 	"io/fs"
 	"log"
@@ -15,12 +16,19 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/jmoiron/sqlx"
+	"github.com/viddrobnic/sparovec/cli"
 	"github.com/viddrobnic/sparovec/config"
 	"github.com/viddrobnic/sparovec/database"
+	"github.com/viddrobnic/sparovec/features/account"
+	"github.com/viddrobnic/sparovec/features/activity"
+	"github.com/viddrobnic/sparovec/features/admin"
 	"github.com/viddrobnic/sparovec/features/auth"
 	"github.com/viddrobnic/sparovec/features/dashboard"
+	"github.com/viddrobnic/sparovec/features/notifications"
+	"github.com/viddrobnic/sparovec/features/reports"
 	"github.com/viddrobnic/sparovec/features/tags"
 	"github.com/viddrobnic/sparovec/features/transactions"
+	"github.com/viddrobnic/sparovec/features/trash"
 	"github.com/viddrobnic/sparovec/features/wallets"
 	"github.com/viddrobnic/sparovec/observability"
 )
@@ -49,52 +57,44 @@ func main() {
 
 	db, err := setupDatabase(conf, logger)
 	if err != nil {
-		return
+		os.Exit(1)
 	}
 
-	if len(os.Args) < 2 {
-		_, _ = fmt.Println("Usage: sparovec <command>")
-		_, _ = fmt.Println("\tserve\t\t\t\t\tStarts the server")
-		_, _ = fmt.Println("\tcreate-user [username] [password]\tCreates a new user with given credentials")
-		return
-	}
-
-	switch os.Args[1] {
-	case "serve":
-		serve(conf, db, logger)
-	case "create-user":
-		if len(os.Args) != 4 {
-			_, _ = fmt.Println("Usage: sparovec create-user [username] [password]")
-			return
-		}
+	app := cli.New(conf, db, logger)
+	app.Add(cli.Command{
+		Name:    "serve",
+		Summary: "Starts the server",
+		Run: func(_ context.Context, flags *flag.FlagSet, args []string) error {
+			err := cli.Parse(flags, args, 0)
+			if err != nil {
+				return err
+			}
 
-		createUser(db, logger, os.Args[2], os.Args[3])
-	default:
-		_, _ = fmt.Println("Unknown command")
-	}
-}
+			err = conf.Auth.ValidateSigningKeys()
+			if err != nil {
+				return fmt.Errorf("invalid config, run generate-signing-key for a new key:\n%w", err)
+			}
 
-func createUser(db *sqlx.DB, logger *slog.Logger, username, password string) {
-	usersRepository := auth.NewRepository(db)
-	usersService := auth.New(usersRepository, nil, logger)
+			return serve(conf, db, logger)
+		},
+	})
 
-	user, err := usersService.CreateUser(context.Background(), username, password)
-	if err != nil {
-		_, _ = fmt.Println("Failed to create user")
-		return
-	}
-
-	_, _ = fmt.Printf("User created: %d\n", user.Id)
+	os.Exit(app.Run(context.Background(), os.Args[1:]))
 }
 
-func serve(conf *config.Config, db *sqlx.DB, logger *slog.Logger) {
+func serve(conf *config.Config, db *sqlx.DB, logger *slog.Logger) error {
 	usersRepository := auth.NewRepository(db)
 	walletsRepository := wallets.NewRepository(db)
 	tagsRepository := tags.NewRepository(db)
 	transactionRepository := transactions.NewRepository(db)
 	dashboardRepository := dashboard.NewRepository(db)
+	adminRepository := admin.NewRepository(db)
+	activityRepository := activity.NewRepository(db)
+	reportsRepository := reports.NewRepository(db)
 
-	authRoutes := auth.New(usersRepository, conf, logger)
+	loginLimiter := auth.NewLoginLimiter(conf, logger.With("where", "login_limiter"))
+
+	authRoutes := auth.New(usersRepository, loginLimiter, conf, logger)
 	walletsRoutes := wallets.New(
 		walletsRepository,
 		usersRepository,
@@ -109,7 +109,6 @@ func serve(conf *config.Config, db *sqlx.DB, logger *slog.Logger) {
 	dashboardRoutes := dashboard.New(
 		dashboardRepository,
 		walletsRepository,
-		transactionsRoutes,
 		logger.With("where", "dashboard_routes"),
 	)
 	tagsRoutes := tags.New(
@@ -117,8 +116,48 @@ func serve(conf *config.Config, db *sqlx.DB, logger *slog.Logger) {
 		tagsRepository,
 		logger.With("where", "tags_routes"),
 	)
+	accountRoutes := account.New(
+		authRoutes,
+		usersRepository,
+		walletsRepository,
+		logger.With("where", "account_routes"),
+	)
+	notificationsRoutes := notifications.New(
+		walletsRepository,
+		logger.With("where", "notifications_routes"),
+	)
+	activityRoutes := activity.New(
+		activityRepository,
+		walletsRepository,
+		logger.With("where", "activity_routes"),
+	)
+	reportsRoutes := reports.New(
+		reportsRepository,
+		walletsRepository,
+		transactionsRoutes,
+		logger.With("where", "reports_routes"),
+	)
+	trashRoutes := trash.New(
+		transactionRepository,
+		tagsRepository,
+		walletsRepository,
+		conf,
+		logger.With("where", "trash_routes"),
+	)
+	adminRoutes := admin.New(
+		authRoutes,
+		adminRepository,
+		walletsRepository,
+		conf,
+		logger.With("where", "admin_routes"),
+	)
+
+	router, err := createRouter(conf, authRoutes, logger.With("where", "router"))
+	if err != nil {
+		logger.Error("Failed to create router", "error", err)
+		return err
+	}
 
-	router := createRouter(conf, authRoutes)
 	staticFs, _ := fs.Sub(assetsDir, "assets")
 	router.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.FS(staticFs))))
 
@@ -127,12 +166,22 @@ func serve(conf *config.Config, db *sqlx.DB, logger *slog.Logger) {
 	dashboardRoutes.Mount(router)
 	tagsRoutes.Mount(router)
 	transactionsRoutes.Mount(router)
+	accountRoutes.Mount(router)
+	activityRoutes.Mount(router)
+	reportsRoutes.Mount(router)
+	trashRoutes.Mount(router)
+	notificationsRoutes.Mount(router)
+	adminRoutes.Mount(router)
+
+	go trashRoutes.PurgePeriodically(context.Background())
 
-	err := http.ListenAndServe(fmt.Sprintf("%s:%d", conf.API.ListenAddress, conf.API.Port), router)
+	err = http.ListenAndServe(fmt.Sprintf("%s:%d", conf.API.ListenAddress, conf.API.Port), router)
 	if err != nil {
 		logger.Error("Failed to start server", "error", err)
-		return
+		return err
 	}
+
+	return nil
 }
 
 func setupDatabase(conf *config.Config, logger *slog.Logger) (*sqlx.DB, error) {
@@ -153,10 +202,28 @@ func setupDatabase(conf *config.Config, logger *slog.Logger) (*sqlx.DB, error) {
 	return db, nil
 }
 
-func createRouter(conf *config.Config, authService auth.Service) chi.Router {
+func createRouter(conf *config.Config, authService *auth.Auth, logger *slog.Logger) (chi.Router, error) {
 	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+
+	// Runs before RealIP, so that forwarded client ips are only used from trusted proxies.
+	peerMiddleware, err := auth.CreatePeerMiddleware(conf.Auth.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	router.Use(peerMiddleware)
+
+	// Runs before RealIP, so that only the address of the proxy itself is trusted.
+	if conf.Auth.Proxy.Enabled {
+		proxyMiddleware, err := auth.CreateProxyMiddleware(conf.Auth.Proxy, authService, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		router.Use(proxyMiddleware)
+	}
+
 	router.Use(
-		middleware.RequestID,
 		middleware.RealIP,
 		middleware.StripSlashes,
 		cors.Handler(cors.Options{
@@ -181,8 +248,9 @@ func createRouter(conf *config.Config, authService auth.Service) chi.Router {
 	router.Use(
 		middleware.Timeout(5*time.Second),
 		auth.CreateMiddleware(authService),
+		auth.CreateCsrfMiddleware(conf.Auth, logger),
 		middleware.Recoverer,
 	)
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,11 @@
+-- NULL when two-factor authentication is disabled.
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+-- Time step of the last accepted code, so that codes can't be reused.
+ALTER TABLE users ADD COLUMN totp_last_counter INTEGER DEFAULT 0 NOT NULL;
+
+CREATE TABLE recovery_codes (
+    id INTEGER NOT NULL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    created_at DATETIME DEFAULT (datetime('now')) NOT NULL
+);
@@ -0,0 +1,10 @@
+-- Incremented when the password changes, which invalidates all existing sessions.
+ALTER TABLE users ADD COLUMN session_version INTEGER DEFAULT 0 NOT NULL;
+
+CREATE TABLE password_resets (
+    id INTEGER NOT NULL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT (datetime('now')) NOT NULL
+);
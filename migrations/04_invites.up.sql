@@ -0,0 +1,12 @@
+CREATE TABLE invites (
+    id INTEGER NOT NULL PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    -- NULL when the invite was created with the cli.
+    created_by INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    -- Wallet the new user becomes a member of, if any.
+    wallet_id INTEGER REFERENCES wallets(id) ON DELETE SET NULL,
+    expires_at DATETIME NOT NULL,
+    used_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    used_at DATETIME,
+    created_at DATETIME DEFAULT (datetime('now')) NOT NULL
+);
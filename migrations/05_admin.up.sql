@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN DEFAULT 0 NOT NULL;
+-- Disabled users can't sign in. NULL when the user is enabled.
+ALTER TABLE users ADD COLUMN disabled_at DATETIME;
@@ -0,0 +1,3 @@
+-- Existing members could do everything, so they all become owners.
+ALTER TABLE wallet_users ADD COLUMN role TEXT DEFAULT 'owner' NOT NULL
+    CHECK (role IN ('owner', 'editor', 'viewer'));
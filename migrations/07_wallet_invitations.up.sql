@@ -0,0 +1,12 @@
+-- Users become members of a wallet only after they accept the invitation.
+CREATE TABLE wallet_invitations (
+    id INTEGER NOT NULL PRIMARY KEY,
+    wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at DATETIME DEFAULT (datetime('now')) NOT NULL,
+    UNIQUE(wallet_id, user_id)
+);
+
+CREATE INDEX wallet_invitations_user_id ON wallet_invitations(user_id);
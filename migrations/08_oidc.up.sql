@@ -0,0 +1,5 @@
+-- Identity of users that sign in with OpenID Connect. The subject is only unique per issuer.
+ALTER TABLE users ADD COLUMN oidc_issuer TEXT;
+ALTER TABLE users ADD COLUMN oidc_subject TEXT;
+
+CREATE UNIQUE INDEX users_oidc_identity ON users(oidc_issuer, oidc_subject);
@@ -0,0 +1,17 @@
+CREATE TABLE audit_log (
+    id INTEGER NOT NULL PRIMARY KEY,
+    wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    -- NULL for changes made with the cli.
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    -- Kept when the user is renamed or deleted.
+    username TEXT,
+    entity TEXT NOT NULL CHECK (entity IN ('transaction', 'tag', 'member', 'invitation', 'wallet')),
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    -- Json lists of fields, NULL for creates and deletes respectively.
+    before TEXT,
+    after TEXT,
+    created_at DATETIME DEFAULT (datetime('now')) NOT NULL
+);
+
+CREATE INDEX audit_log_wallet ON audit_log(wallet_id, created_at);
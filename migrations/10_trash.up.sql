@@ -0,0 +1,28 @@
+-- Deleted rows stay in the trash until they are purged.
+ALTER TABLE transactions ADD COLUMN deleted_at DATETIME;
+ALTER TABLE tags ADD COLUMN deleted_at DATETIME;
+ALTER TABLE wallets ADD COLUMN deleted_at DATETIME;
+
+-- Restores from the trash are recorded in the audit log, which needs
+-- a new check constraint. Nothing references the audit log, so it can be recreated.
+CREATE TABLE audit_log_new (
+    id INTEGER NOT NULL PRIMARY KEY,
+    wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    -- NULL for changes made with the cli.
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    -- Kept when the user is renamed or deleted.
+    username TEXT,
+    entity TEXT NOT NULL CHECK (entity IN ('transaction', 'tag', 'member', 'invitation', 'wallet')),
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    -- Json lists of fields, NULL for creates and deletes respectively.
+    before TEXT,
+    after TEXT,
+    created_at DATETIME DEFAULT (datetime('now')) NOT NULL
+);
+
+INSERT INTO audit_log_new SELECT * FROM audit_log;
+DROP TABLE audit_log;
+ALTER TABLE audit_log_new RENAME TO audit_log;
+
+CREATE INDEX audit_log_wallet ON audit_log(wallet_id, created_at);
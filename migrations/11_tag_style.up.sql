@@ -0,0 +1,15 @@
+ALTER TABLE tags ADD COLUMN color TEXT NOT NULL DEFAULT '#64748b';
+ALTER TABLE tags ADD COLUMN icon TEXT NOT NULL DEFAULT '';
+ALTER TABLE tags ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+-- Give existing tags different colors, like the dashboard chart used to.
+UPDATE tags SET color = CASE id % 8
+    WHEN 0 THEN '#ef4444'
+    WHEN 1 THEN '#f97316'
+    WHEN 2 THEN '#eab308'
+    WHEN 3 THEN '#22c55e'
+    WHEN 4 THEN '#06b6d4'
+    WHEN 5 THEN '#3b82f6'
+    WHEN 6 THEN '#a855f7'
+    ELSE '#ec4899'
+END;
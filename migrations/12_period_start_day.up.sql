@@ -0,0 +1,3 @@
+-- Day of the month on which budgeting periods of the wallet start. Limited to 28,
+-- so that every month has the day.
+ALTER TABLE wallets ADD COLUMN period_start_day INTEGER NOT NULL DEFAULT 1 CHECK (period_start_day BETWEEN 1 AND 28);
@@ -0,0 +1,3 @@
+-- Dashboard and trends sum transactions of a wallet by date and by tag.
+CREATE INDEX transactions_wallet_created_at ON transactions(wallet_id, created_at);
+CREATE INDEX transactions_wallet_tag ON transactions(wallet_id, tag_id);
@@ -0,0 +1,2 @@
+-- Secret shown during two-factor setup, until the user confirms it with a code.
+ALTER TABLE users ADD COLUMN totp_pending_secret TEXT;
@@ -0,0 +1,11 @@
+package models
+
+type InstanceStats struct {
+	Users        int
+	Wallets      int
+	Transactions int
+	Tags         int
+
+	// In bytes.
+	DatabaseSize int64
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AuditEntity is the kind of entity that was changed.
+type AuditEntity string
+
+const (
+	AuditTransaction AuditEntity = "transaction"
+	AuditTag         AuditEntity = "tag"
+	AuditMember      AuditEntity = "member"
+	AuditInvitation  AuditEntity = "invitation"
+	AuditWallet      AuditEntity = "wallet"
+)
+
+var AuditEntities = []AuditEntity{
+	AuditTransaction,
+	AuditTag,
+	AuditMember,
+	AuditInvitation,
+	AuditWallet,
+}
+
+func (e AuditEntity) IsValid() bool {
+	for _, entity := range AuditEntities {
+		if e == entity {
+			return true
+		}
+	}
+
+	return false
+}
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+	// AuditRestore is a restore from the trash.
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditField is a field of an entity, formatted for display.
+type AuditField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// AuditEntry is a change of an entity in a wallet.
+type AuditEntry struct {
+	Id       int
+	WalletId int `db:"wallet_id"`
+	// Not set for changes made with the cli, or if the user was deleted.
+	UserId sql.NullInt64 `db:"user_id"`
+	// Username at the time of the change. Not set for changes made with the cli.
+	Username sql.NullString
+	Entity   AuditEntity
+	EntityId int `db:"entity_id"`
+	Action   AuditAction
+	// Fields before and after the change, encoded as json.
+	Before    sql.NullString
+	After     sql.NullString
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type AuditListRequest struct {
+	WalletId int
+	// Only entries of this user, if set.
+	UserId int
+	// Only entries of this entity, if set.
+	Entity AuditEntity
+	Page   *Page
+}
+
+// AuditActor is a user that appears in the audit log of a wallet.
+type AuditActor struct {
+	UserId   int `db:"user_id"`
+	Username string
+}
@@ -1,3 +1,6 @@
 package models
 
-const SessionCookieName = "session"
+const (
+	SessionCookieName        = "session"
+	PendingSessionCookieName = "session_pending"
+)
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type TagBalance struct {
 	Tag     *Tag
 	Balance int
@@ -12,3 +14,51 @@ type DashboardData struct {
 	NrTransactions int
 	TagBalance     []TagBalance
 }
+
+// DateRange is a range of days. Both days are included.
+// A zero From or To means that the range is unbounded on that side.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Totals are the sums of the transactions in a range.
+type Totals struct {
+	Income         int
+	Outcome        int
+	NrTransactions int `db:"nr_transactions"`
+}
+
+func (t Totals) Balance() int {
+	return t.Income + t.Outcome
+}
+
+// MonthTotals are the sums of the transactions in one period of a wallet.
+type MonthTotals struct {
+	// First day of the period.
+	Month   time.Time
+	Income  int
+	Outcome int
+}
+
+// TagSpending is the sum of expenses with the tag in one period of a wallet.
+type TagSpending struct {
+	// First day of the period.
+	Month time.Time
+	// Nil for transactions without a tag.
+	Tag     *Tag
+	Outcome int
+}
+
+// NameTotals are the sums of transactions with the same name and tag in one month.
+// Names are compared without case and surrounding spaces.
+type NameTotals struct {
+	Name string
+	// Nil for transactions without a tag.
+	Tag            *Tag
+	Month          time.Time
+	NrTransactions int
+	Value          int
+	// Day of the month of the last transaction.
+	LastDay int
+}
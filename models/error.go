@@ -9,6 +9,7 @@ var (
 	ErrInternalServer     = errors.New("internal server error")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrForbidden          = errors.New("forbidden")
+	ErrNotFound           = errors.New("not found")
 )
 
 type ErrInvalidForm struct {
@@ -18,3 +19,12 @@ type ErrInvalidForm struct {
 func (e *ErrInvalidForm) Error() string {
 	return fmt.Sprintf("invalid form: %s", e.Message)
 }
+
+// ErrTagExists is returned when a tag is renamed to the name of another tag.
+type ErrTagExists struct {
+	Tag *Tag
+}
+
+func (e *ErrTagExists) Error() string {
+	return fmt.Sprintf("tag %q already exists", e.Tag.Name)
+}
@@ -17,5 +17,6 @@ type Navbar struct {
 	SelectedWalletId int
 	Wallets          []*Wallet
 	Username         string
+	IsAdmin          bool
 	Title            string
 }
@@ -1,8 +1,14 @@
 package models
 
+import (
+	"database/sql"
+	"time"
+)
+
 type Member struct {
 	Id       int
 	Username string
+	Role     Role
 	IsSelf   bool
 }
 
@@ -12,3 +18,28 @@ type SettingsContext struct {
 	WalletName string
 	Members    []*Member
 }
+
+// IsLastOwner returns true if the user is the only owner among the members.
+// A wallet must always have an owner.
+func IsLastOwner(members []*Member, userId int) bool {
+	for _, member := range members {
+		if member.Role == RoleOwner && member.Id != userId {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Invitation is a pending invitation of a user to a wallet.
+type Invitation struct {
+	Id         int
+	WalletId   int    `db:"wallet_id"`
+	WalletName string `db:"wallet_name"`
+	// Username of the invited user.
+	Username string
+	// Username of the user who sent the invitation. Not set if the user was deleted.
+	InvitedBy sql.NullString `db:"invited_by"`
+	Role      Role
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -1,12 +1,48 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
+
+// DefaultTagColor is the color of new tags and of transactions without a tag.
+const DefaultTagColor = "#64748b"
+
+// TagColors are the colors offered when editing a tag.
+var TagColors = []string{
+	"#ef4444",
+	"#f97316",
+	"#eab308",
+	"#22c55e",
+	"#06b6d4",
+	"#3b82f6",
+	"#a855f7",
+	"#ec4899",
+	DefaultTagColor,
+}
 
 type Tag struct {
-	Id        int
-	WalletId  int `db:"wallet_id"`
-	Name      string
-	CreatedAt time.Time `db:"created_at"`
+	Id       int
+	WalletId int `db:"wallet_id"`
+	Name     string
+	// Hex color, like #ef4444.
+	Color string
+	// Name of an icon from the bundled set, empty for no icon.
+	Icon        string
+	Description string
+	CreatedAt   time.Time `db:"created_at"`
+	// Set for tags in the trash.
+	DeletedAt sql.NullTime `db:"deleted_at"`
+}
+
+// OtherTag returns the pseudo tag that transactions without a tag are grouped under.
+// The wallet id can be zero when the tag is not tied to one wallet.
+func OtherTag(walletId int) *Tag {
+	return &Tag{
+		Name:     "Other",
+		WalletId: walletId,
+		Color:    DefaultTagColor,
+	}
 }
 
 type TagsContext struct {
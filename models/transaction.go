@@ -18,6 +18,7 @@ type Transaction struct {
 	Value     int
 	Tag       *Tag
 	CreatedAt time.Time
+	DeletedAt sql.NullTime
 }
 type TransactionRender struct {
 	Id            int
@@ -98,6 +99,9 @@ type DbTransaction struct {
 	CreatedAt time.Time `db:"created_at"`
 
 	TagId sql.NullInt32 `db:"tag_id"`
+
+	// Set for transactions in the trash.
+	DeletedAt sql.NullTime `db:"deleted_at"`
 }
 
 func (dt *DbTransaction) ToModel() *Transaction {
@@ -115,5 +119,6 @@ func (dt *DbTransaction) ToModel() *Transaction {
 		Value:     dt.Value,
 		Tag:       tag,
 		CreatedAt: dt.CreatedAt,
+		DeletedAt: dt.DeletedAt,
 	}
 }
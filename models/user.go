@@ -1,6 +1,8 @@
 package models
 
 import (
+	"context"
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -12,18 +14,71 @@ type User struct {
 	Id        int       `json:"id"`
 	Username  string    `json:"username"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// Not stored in the session, so that revoking admin takes effect immediately.
+	IsAdmin bool `json:"-" db:"is_admin"`
+}
+
+type contextKey string
+
+const contextKeyUser = contextKey("user")
+
+// ContextWithUser stores the signed in user in the context.
+func ContextWithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, contextKeyUser, user)
+}
+
+// UserFromContext returns the signed in user, or nil if there is none.
+func UserFromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(contextKeyUser).(*User)
+	return user
 }
 
 type UserCredentials struct {
 	User
 	Password string
 	Salt     string
+
+	TotpSecret      sql.NullString `db:"totp_secret"`
+	TotpLastCounter int64          `db:"totp_last_counter"`
+	// Secret of a two-factor setup that wasn't confirmed yet.
+	TotpPendingSecret sql.NullString `db:"totp_pending_secret"`
+
+	SessionVersion int `db:"session_version"`
+
+	DisabledAt sql.NullTime `db:"disabled_at"`
+
+	// Set for users that sign in with OpenID Connect.
+	OidcIssuer  sql.NullString `db:"oidc_issuer"`
+	OidcSubject sql.NullString `db:"oidc_subject"`
+}
+
+func (uc *UserCredentials) HasTwoFactor() bool {
+	return uc.TotpSecret.Valid
+}
+
+func (uc *UserCredentials) IsDisabled() bool {
+	return uc.DisabledAt.Valid
+}
+
+// HasPassword returns false for users that were created by
+// signing in with OpenID Connect and never set a password.
+func (uc *UserCredentials) HasPassword() bool {
+	return uc.Password != ""
 }
 
 type Session struct {
 	User      *User     `json:"user"`
 	ExpiresAt time.Time `json:"expires_at"`
-	Signature string    `json:"-"`
+	KeyId     string    `json:"kid,omitempty"`
+	// Must match the user's session version, which changes with the password.
+	Version int `json:"ver,omitempty"`
+
+	// Password was correct, but the second factor still has to be checked.
+	// Such sessions are only valid for completing the sign in.
+	TwoFactorPending bool `json:"two_factor_pending,omitempty"`
+
+	Signature string `json:"-"`
 }
 
 func (sess *Session) ToCookie() (string, error) {
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type WalletsContext struct {
 	Navbar *NavbarContext
@@ -12,4 +15,68 @@ type Wallet struct {
 	Id        int
 	Name      string
 	CreatedAt time.Time `db:"created_at"`
+	// Day of the month on which periods of the wallet start, between 1 and MaxPeriodStartDay.
+	PeriodStartDay int `db:"period_start_day"`
+	// Set for wallets in the trash.
+	DeletedAt sql.NullTime `db:"deleted_at"`
+	// Role of the user, only set when listing wallets of a user.
+	Role Role
+}
+
+// MaxPeriodStartDay is the last day on which periods can start, so that every month has it.
+const MaxPeriodStartDay = 28
+
+// Period returns the period of the wallet that contains the day. Periods are calendar
+// months if they start on the first day, and otherwise run from the start day to the
+// day before it in the next month, like 15. 3. – 14. 4.
+func (w *Wallet) Period(day time.Time) DateRange {
+	startDay := w.PeriodStartDay
+	if startDay < 1 {
+		startDay = 1
+	}
+
+	year, month, dayOfMonth := day.Date()
+	if dayOfMonth < startDay {
+		month--
+	}
+
+	from := time.Date(year, month, startDay, 0, 0, 0, 0, time.UTC)
+	return DateRange{From: from, To: from.AddDate(0, 1, -1)}
+}
+
+// Role is the role of a member in a wallet. Each role can do
+// everything the roles below it can.
+type Role string
+
+const (
+	// RoleViewer can only read the wallet.
+	RoleViewer Role = "viewer"
+	// RoleEditor can also manage transactions and tags.
+	RoleEditor Role = "editor"
+	// RoleOwner can also manage members, rename and delete the wallet.
+	RoleOwner Role = "owner"
+)
+
+var Roles = []Role{RoleOwner, RoleEditor, RoleViewer}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleOwner:
+		return 3
+	case RoleEditor:
+		return 2
+	case RoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Includes returns true if the role can do everything the other role can.
+func (r Role) Includes(other Role) bool {
+	return r.rank() > 0 && r.rank() >= other.rank()
+}
+
+func (r Role) IsValid() bool {
+	return r.rank() > 0
 }
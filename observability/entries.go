@@ -0,0 +1,121 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/viddrobnic/sparovec/config"
+)
+
+// Only the end of the log file is read, so that big files don't have to be read whole.
+const maxRecentBytes = 1 << 20
+
+var ErrFileLoggingDisabled = errors.New("logging to file is disabled")
+
+type LogEntry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Remaining attributes, formatted as key=value.
+	Attrs string
+}
+
+// ReadRecent returns up to limit most recent entries with at least the given level
+// from the current log file, newest first. Rotated files are not read.
+func ReadRecent(conf *config.Config, limit int, minLevel slog.Level) ([]LogEntry, error) {
+	if !conf.Observability.WriteToFile {
+		return nil, ErrFileLoggingDisabled
+	}
+
+	f, err := os.Open(conf.Observability.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []LogEntry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := max(info.Size()-maxRecentBytes, 0)
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	if offset > 0 {
+		// First line is probably cut in half.
+		lines = lines[1:]
+	}
+
+	entries := []LogEntry{}
+	for i := len(lines) - 1; i >= 0 && len(entries) < limit; i-- {
+		entry, ok := parseEntry(lines[i])
+		if !ok || entry.Level < minLevel {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseEntry parses a line written by the json handler.
+func parseEntry(line []byte) (LogEntry, bool) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return LogEntry{}, false
+	}
+
+	fields := map[string]any{}
+	err := json.Unmarshal(line, &fields)
+	if err != nil {
+		return LogEntry{}, false
+	}
+
+	entry := LogEntry{}
+	if value, ok := fields[slog.TimeKey].(string); ok {
+		entry.Time, _ = time.Parse(time.RFC3339Nano, value)
+	}
+	if value, ok := fields[slog.LevelKey].(string); ok {
+		_ = entry.Level.UnmarshalText([]byte(value))
+	}
+	if value, ok := fields[slog.MessageKey].(string); ok {
+		entry.Message = value
+	}
+
+	delete(fields, slog.TimeKey)
+	delete(fields, slog.LevelKey)
+	delete(fields, slog.MessageKey)
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]string, len(keys))
+	for i, key := range keys {
+		attrs[i] = fmt.Sprintf("%s=%v", key, fields[key])
+	}
+	entry.Attrs = strings.Join(attrs, " ")
+
+	return entry, true
+}